@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"os"
+)
+
+// apiToken is the supabase-auth access token sent to the API as a bearer
+// token; $AIRLANCE_TOKEN is used when the flag is not given.
+var apiToken string
+
+// newAPIRequest builds a request to the API, authenticated when a token is
+// set.
+func newAPIRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	token := apiToken
+	if token == "" {
+		token = os.Getenv("AIRLANCE_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// getAPI is http.Get for API URLs.
+func getAPI(url string) (*http.Response, error) {
+	req, err := newAPIRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listStatuses      []string
+	listMediaType     string
+	listOwner         string
+	listCreatedAfter  string
+	listCreatedBefore string
+	listSort          string
+	listLimit         int
+	listCursor        string
+	listAll           bool
+	listJSON          bool
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage jobs",
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your jobs",
+	Long:  `List jobs newest first, one page at a time. Admin tokens list every user's jobs and may filter by --owner.`,
+	Run:   runJobsList,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <uuid>",
+	Short: "Cancel a job that has not finished",
+	Args:  cobra.ExactArgs(1),
+	Run:   runJobsCancel,
+}
+
+var jobsDeleteCmd = &cobra.Command{
+	Use:   "delete <uuid>",
+	Short: "Delete a job and its files",
+	Long:  `Delete a job's inputs and outputs, cancelling it first if it is still running.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runJobsDelete,
+}
+
+func init() {
+	jobsCmd.PersistentFlags().StringVarP(&apiURL, "url", "u", "http://localhost:8080", "API URL")
+	jobsCmd.PersistentFlags().StringVarP(&apiToken, "token", "t", "", "Access token from supabase-auth (default $AIRLANCE_TOKEN)")
+
+	jobsListCmd.Flags().StringSliceVarP(&listStatuses, "status", "s", nil, "Only jobs in these statuses: uploading, pending, processing, ready, failed, cancelled")
+	jobsListCmd.Flags().StringVarP(&listMediaType, "media-type", "m", "", "Only jobs whose media input is an image, video or audio")
+	jobsListCmd.Flags().StringVar(&listOwner, "owner", "", "Only jobs of this user (admins only)")
+	jobsListCmd.Flags().StringVar(&listCreatedAfter, "created-after", "", "Only jobs created at or after this RFC 3339 time")
+	jobsListCmd.Flags().StringVar(&listCreatedBefore, "created-before", "", "Only jobs created before this RFC 3339 time")
+	jobsListCmd.Flags().StringVar(&listSort, "sort", "", "Sort by created_at or updated_at, descending with a leading - (default -created_at)")
+	jobsListCmd.Flags().IntVarP(&listLimit, "limit", "l", 20, "Jobs per page (at most 100)")
+	jobsListCmd.Flags().StringVar(&listCursor, "cursor", "", "Continue from the next_cursor of a previous page")
+	jobsListCmd.Flags().BoolVarP(&listAll, "all", "A", false, "Follow cursors and list every matching job")
+	jobsListCmd.Flags().BoolVar(&listJSON, "json", false, "Print the response as JSON instead of a table")
+
+	jobsCmd.AddCommand(jobsListCmd, jobsCancelCmd, jobsDeleteCmd)
+}
+
+func runJobsList(cmd *cobra.Command, args []string) {
+	query := url.Values{}
+	for _, status := range listStatuses {
+		query.Add("status", status)
+	}
+	setQuery(query, "media_type", listMediaType)
+	setQuery(query, "owner", listOwner)
+	setQuery(query, "created_after", listCreatedAfter)
+	setQuery(query, "created_before", listCreatedBefore)
+	setQuery(query, "sort", listSort)
+	query.Set("limit", strconv.Itoa(listLimit))
+
+	var list dto.JobListResponse
+	cursor := listCursor
+	for {
+		setQuery(query, "cursor", cursor)
+		page, err := fetchJobs(apiURL + "/jobs?" + query.Encode())
+		if err != nil {
+			fmt.Printf("❌ Failed to list jobs: %v\n", err)
+			os.Exit(1)
+		}
+
+		list.Jobs = append(list.Jobs, page.Jobs...)
+		list.NextCursor = page.NextCursor
+		if !listAll || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if listJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(list)
+		return
+	}
+
+	if len(list.Jobs) == 0 {
+		fmt.Println("No jobs found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UUID\tSTATUS\tPROGRESS\tTYPE\tMEDIA\tPRESET\tCREATED")
+	for _, job := range list.Jobs {
+		fmt.Fprintf(w, "%s\t%s\t%.0f%%\t%s\t%s\t%s\t%s\n",
+			job.UUID,
+			job.Status,
+			job.Progress,
+			job.Type,
+			orDash(job.MediaType),
+			job.Preset,
+			job.CreatedAt.Local().Format(time.DateTime),
+		)
+	}
+	w.Flush()
+
+	if list.NextCursor != "" {
+		fmt.Printf("\nMore jobs: --cursor %s\n", list.NextCursor)
+	}
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) {
+	if err := sendJobRequest(http.MethodPost, apiURL+"/jobs/"+args[0]+"/cancel", http.StatusOK); err != nil {
+		fmt.Printf("❌ Failed to cancel job: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Job %s cancelled\n", args[0])
+}
+
+func runJobsDelete(cmd *cobra.Command, args []string) {
+	if err := sendJobRequest(http.MethodDelete, apiURL+"/jobs/"+args[0], http.StatusNoContent); err != nil {
+		fmt.Printf("❌ Failed to delete job: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Job %s deleted\n", args[0])
+}
+
+// sendJobRequest sends a bodiless request and checks the response status.
+func sendJobRequest(method, url string, want int) error {
+	req, err := newAPIRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func fetchJobs(url string) (*dto.JobListResponse, error) {
+	resp, err := getAPI(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var page dto.JobListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &page, nil
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value == "" {
+		query.Del(key)
+		return
+	}
+	query.Set(key, value)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
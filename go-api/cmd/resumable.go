@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+)
+
+const (
+	// Chunks start small and are sized to take about tusChunkTime each at
+	// the rate the previous one went, so a slow link never sends more than
+	// its server deadline allows and a failed chunk wastes little.
+	tusMinChunkSize   = 256 << 10
+	tusStartChunkSize = 1 << 20
+	tusMaxChunkSize   = 8 << 20
+	tusChunkTime      = 10 * time.Second
+	tusMaxAttempts    = 10
+)
+
+// resumeState remembers unfinished resumable uploads across runs, keyed by
+// the API and the paths, sizes and modification times of the inputs.
+type resumeState map[string]*resumeEntry
+
+type resumeEntry struct {
+	UUID   string            `json:"uuid"`
+	Inputs map[string]string `json:"inputs"` // input name -> tus upload URL
+}
+
+// runResumableUpload uploads the inputs through a tus session, picking up
+// where an interrupted run of the same upload left off, and returns the
+// job UUID.
+func runResumableUpload() (string, error) {
+	inputs := []struct {
+		name, path string
+	}{
+		{"media", mediaPath},
+		{"audio", audioPath},
+	}
+
+	key := apiURL
+	for _, input := range inputs {
+		info, err := os.Stat(input.path)
+		if err != nil {
+			return "", err
+		}
+		abs, _ := filepath.Abs(input.path)
+		key += fmt.Sprintf("|%s:%d:%d", abs, info.Size(), info.ModTime().UnixNano())
+	}
+
+	statePath, state := loadResumeState()
+	entry := state[key]
+	if entry != nil && jobStatus(entry.UUID) != "uploading" {
+		entry = nil
+	}
+	if entry == nil {
+		jobUUID, err := createTusSession()
+		if err != nil {
+			return "", err
+		}
+		entry = &resumeEntry{UUID: jobUUID, Inputs: map[string]string{}}
+		state[key] = entry
+		saveResumeState(statePath, state)
+	} else {
+		fmt.Printf("🔁 Resuming upload of job %s\n", entry.UUID)
+	}
+
+	for _, input := range inputs {
+		err := uploadTus(entry, input.name, input.path, func() { saveResumeState(statePath, state) })
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", input.name, err)
+		}
+	}
+
+	delete(state, key)
+	saveResumeState(statePath, state)
+
+	return entry.UUID, nil
+}
+
+func createTusSession() (string, error) {
+	req := dto.UploadSessionRequest{
+		Media:              sessionFile(mediaPath),
+		Audio:              sessionFile(audioPath),
+		Preset:             preset,
+		OutputMode:         output,
+		Motion:             motion,
+		Visualizer:         visualizer,
+		VisualizerColor:    visualizerColor,
+		VisualizerPosition: visualizerPosition,
+		Loudnorm:           loudnorm,
+		LoudnormTarget:     loudnormTarget,
+		LoudnormTruePeak:   loudnormTruePeak,
+		Transport:          usecase.TransportTus,
+	}
+	body, _ := json.Marshal(req)
+
+	httpReq, _ := newAPIRequest(http.MethodPost, apiURL+"/upload/session", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("create session: %s", strings.TrimSpace(string(msg)))
+	}
+
+	var session dto.UploadSessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return "", err
+	}
+	return session.UUID, nil
+}
+
+func sessionFile(path string) dto.SessionFile {
+	info, _ := os.Stat(path)
+	return dto.SessionFile{
+		Filename:    filepath.Base(path),
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(path)),
+	}
+}
+
+// uploadTus sends one input, creating its tus upload unless the entry
+// already has one, and retries failed chunks from the offset the server
+// reports.
+func uploadTus(entry *resumeEntry, input, path string, save func()) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	var offset int64
+	chunk := int64(tusStartChunkSize)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if attempt == tusMaxAttempts {
+				return err
+			}
+			backoff := min(time.Second<<(attempt-1), 30*time.Second)
+			fmt.Printf("\n⚠️  %v, retrying in %s\n", err, backoff)
+			time.Sleep(backoff)
+		}
+
+		if entry.Inputs[input] == "" {
+			var location string
+			if location, err = createTusUpload(entry.UUID, input, path, size); err != nil {
+				continue
+			}
+			entry.Inputs[input] = location
+			save()
+		}
+
+		var status int
+		if offset, status, err = tusOffset(entry.Inputs[input]); status == http.StatusNotFound {
+			entry.Inputs[input] = ""
+			err = fmt.Errorf("upload expired")
+			continue
+		} else if err != nil {
+			continue
+		}
+
+		for offset < size && err == nil {
+			fmt.Printf("\r📤 %s %5.1f%%", input, float64(offset)*100/float64(size))
+			n := min(chunk, size-offset)
+			started := time.Now()
+			if offset, err = patchTus(entry.Inputs[input], file, offset, n); err != nil {
+				chunk = max(chunk/2, tusMinChunkSize)
+			} else {
+				chunk = nextChunkSize(chunk, n, time.Since(started))
+				attempt = 0
+			}
+		}
+		if err == nil {
+			fmt.Printf("\r📤 %s 100.0%%\n", input)
+			return nil
+		}
+	}
+}
+
+// nextChunkSize sizes the chunk after one of n bytes that took d to send,
+// growing at most fourfold at a time.
+func nextChunkSize(chunk, n int64, d time.Duration) int64 {
+	next := int64(float64(n) * tusChunkTime.Seconds() / max(d, time.Millisecond).Seconds())
+	return min(max(next, tusMinChunkSize), 4*chunk, tusMaxChunkSize)
+}
+
+func createTusUpload(jobUUID, input, path string, size int64) (string, error) {
+	metadata := []string{
+		"job " + base64.StdEncoding.EncodeToString([]byte(jobUUID)),
+		"input " + base64.StdEncoding.EncodeToString([]byte(input)),
+		"filename " + base64.StdEncoding.EncodeToString([]byte(filepath.Base(path))),
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		metadata = append(metadata, "filetype "+base64.StdEncoding.EncodeToString([]byte(contentType)))
+	}
+
+	req, _ := newAPIRequest(http.MethodPost, apiURL+"/files", nil)
+	req.Header.Set("Tus-Resumable", usecase.TusVersion)
+	req.Header.Set("Upload-Length", strconv.FormatInt(size, 10))
+	req.Header.Set("Upload-Metadata", strings.Join(metadata, ","))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("create upload: %s", strings.TrimSpace(string(msg)))
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+func tusOffset(location string) (int64, int, error) {
+	req, _ := newAPIRequest(http.MethodHead, location, nil)
+	req.Header.Set("Tus-Resumable", usecase.TusVersion)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, resp.StatusCode, fmt.Errorf("get offset: %s", resp.Status)
+	}
+	offset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	return offset, resp.StatusCode, err
+}
+
+func patchTus(location string, file *os.File, offset, n int64) (int64, error) {
+	chunk := make([]byte, n)
+	if _, err := file.ReadAt(chunk, offset); err != nil {
+		return offset, err
+	}
+	sum := sha1.Sum(chunk)
+
+	req, _ := newAPIRequest(http.MethodPatch, location, bytes.NewReader(chunk))
+	req.Header.Set("Tus-Resumable", usecase.TusVersion)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	req.Header.Set("Upload-Checksum", "sha1 "+base64.StdEncoding.EncodeToString(sum[:]))
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(resp.Body)
+		return offset, fmt.Errorf("upload chunk: %s", strings.TrimSpace(string(msg)))
+	}
+	return strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+}
+
+func jobStatus(jobUUID string) string {
+	resp, err := getAPI(fmt.Sprintf("%s/status/%s", apiURL, jobUUID))
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var status dto.StatusResponse
+	json.NewDecoder(resp.Body).Decode(&status)
+	return status.Status
+}
+
+func loadResumeState() (string, resumeState) {
+	state := resumeState{}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", state
+	}
+	path := filepath.Join(dir, "airlance", "uploads.json")
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return path, state
+}
+
+func saveResumeState(path string, state resumeState) {
+	if path == "" {
+		return
+	}
+	data, _ := json.MarshalIndent(state, "", "  ")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, data, 0o644)
+}
@@ -24,4 +24,6 @@ func Execute() {
 func init() {
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(uploadCmd)
+	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(jobsCmd)
 }
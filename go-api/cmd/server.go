@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,17 +11,22 @@ import (
 	"time"
 
 	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/repository"
 	"github.com/airlance/api/internal/domain/service"
 	handler2 "github.com/airlance/api/internal/http/handler"
 	"github.com/airlance/api/internal/http/router"
+	"github.com/airlance/api/internal/infrastructure/auth"
 	"github.com/airlance/api/internal/infrastructure/config"
 	"github.com/airlance/api/internal/infrastructure/persistence"
 	"github.com/airlance/api/internal/infrastructure/queue"
+	"github.com/airlance/api/internal/infrastructure/ratelimit"
 	"github.com/airlance/api/internal/infrastructure/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var jobStore string
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start the API server",
@@ -27,51 +34,125 @@ var serverCmd = &cobra.Command{
 	Run:   runServer,
 }
 
+func init() {
+	serverCmd.Flags().StringVar(&jobStore, "job-store", "", "Job store backend: postgres, sqlite or memory (default $JOB_STORE or memory)")
+}
+
 func runServer(cmd *cobra.Command, args []string) {
-	cfg := config.Load()
 	logger := setupLogger()
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load config")
+	}
+
+	if jobStore != "" {
+		cfg.Database.Driver = jobStore
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"port":     cfg.Server.Port,
 		"minio":    cfg.MinIO.Endpoint,
 		"rabbitmq": cfg.RabbitMQ.QueueName,
+		"jobs":     cfg.Database.Driver,
 	}).Info("Starting API server")
 
 	// Infrastructure
-	storageRepo, err := storage.NewMinIOStorage(
-		cfg.MinIO.Endpoint,
-		cfg.MinIO.AccessKey,
-		cfg.MinIO.SecretKey,
-		cfg.MinIO.Bucket,
-		cfg.MinIO.UseSSL,
-	)
+	storageRepo, err := storage.NewMinIOStorage(cfg.MinIO)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize storage")
 	}
 
-	queueRepo, err := queue.NewRabbitMQQueue(cfg.RabbitMQ.URL, cfg.RabbitMQ.QueueName)
+	queueRepo, err := queue.NewRabbitMQQueue(cfg.RabbitMQ.URL, cfg.RabbitMQ.QueueName, cfg.RabbitMQ.CancelExchange)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize queue")
 	}
 	defer queueRepo.Close()
 
-	jobRepo := persistence.NewMemoryJobRepository()
+	statusConsumer, err := queue.NewRabbitMQStatusConsumer(cfg.RabbitMQ.URL, cfg.RabbitMQ.StatusExchange, cfg.RabbitMQ.StatusQueue)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize status consumer")
+	}
+	defer statusConsumer.Close()
+
+	jobRepo, closeJobRepo, err := newJobRepository(cfg.Database)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize job store")
+	}
+	defer closeJobRepo()
 
 	// Domain Services
-	validationSvc := service.NewValidationService()
+	validationSvc := service.NewValidationService(service.UploadLimits{
+		MaxImageSize:      int64(cfg.Upload.MaxImageSizeMB) << 20,
+		MaxVideoSize:      int64(cfg.Upload.MaxVideoSizeMB) << 20,
+		MaxAudioSize:      int64(cfg.Upload.MaxAudioSizeMB) << 20,
+		MaxVideoDuration:  cfg.Upload.MaxVideoDuration,
+		MaxAudioDuration:  cfg.Upload.MaxAudioDuration,
+		MaxImageDimension: cfg.Upload.MaxImageDimension,
+		MaxVideoDimension: cfg.Upload.MaxVideoDimension,
+	})
+
+	var tokenVerifier repository.TokenVerifier
+	if cfg.Auth.Enabled() {
+		tokenVerifier = auth.NewJWTVerifier(cfg.Auth)
+	} else {
+		logrus.Warn("AUTH_JWT_SECRET and AUTH_JWKS_URL are unset, authentication is disabled")
+	}
+
+	streamSecret := []byte(cfg.Auth.StreamSecret)
+	if len(streamSecret) == 0 {
+		streamSecret = make([]byte, 32)
+		if _, err := rand.Read(streamSecret); err != nil {
+			logrus.WithError(err).Fatal("Failed to generate stream URL secret")
+		}
+		if cfg.Auth.Enabled() {
+			logrus.Warn("STREAM_URL_SECRET is unset, stream URLs only work on this instance until it restarts")
+		}
+	}
+	streamSigner := auth.NewHMACStreamSigner(streamSecret)
+
+	var uploadRatePerUser, uploadRatePerIP repository.RateLimiter
+	if cfg.Quota.UploadRatePerUser.Enabled() {
+		uploadRatePerUser = ratelimit.NewMemoryRateLimiter(cfg.Quota.UploadRatePerUser)
+	}
+	if cfg.Quota.UploadRatePerIP.Enabled() {
+		uploadRatePerIP = ratelimit.NewMemoryRateLimiter(cfg.Quota.UploadRatePerIP)
+	}
+
+	retentionPolicy := usecase.RetentionPolicy{
+		DeleteInputs:      cfg.Retention.DeleteInputs,
+		OutputsFor:        time.Duration(cfg.Retention.OutputDays) * 24 * time.Hour,
+		FailedFor:         time.Duration(cfg.Retention.FailedDays) * 24 * time.Hour,
+		StaleUploadsAfter: cfg.Retention.StaleUploadAfter,
+	}
 
 	// Use Cases
-	uploadUseCase := usecase.NewUploadUseCase(jobRepo, storageRepo, queueRepo, validationSvc, logger)
-	statusUseCase := usecase.NewStatusUseCase(jobRepo, storageRepo, cfg.Server.BaseURL)
-	downloadUseCase := usecase.NewDownloadUseCase(storageRepo)
+	quotaUseCase := usecase.NewQuotaUseCase(jobRepo, int64(cfg.Quota.StorageMB)<<20, time.Duration(cfg.Quota.ProcessingMinutes)*time.Minute)
+	uploadUseCase := usecase.NewUploadUseCase(jobRepo, storageRepo, queueRepo, validationSvc, quotaUseCase, logger)
+	statusUseCase := usecase.NewStatusUseCase(jobRepo, storageRepo, streamSigner, cfg.Auth.StreamURLExpiry, cfg.Server.BaseURL)
+	downloadUseCase := usecase.NewDownloadUseCase(jobRepo, storageRepo, streamSigner, cfg.MinIO.PresignExpiry)
+	sessionUseCase := usecase.NewUploadSessionUseCase(jobRepo, storageRepo, queueRepo, validationSvc, quotaUseCase, logger, cfg.Server.BaseURL, cfg.MinIO.PresignExpiry)
+	tusUseCase := usecase.NewTusUseCase(jobRepo, storageRepo, sessionUseCase, validationSvc, logger)
+	artifactUseCase := usecase.NewArtifactUseCase(jobRepo, storageRepo, cfg.Server.BaseURL)
+	jobListUseCase := usecase.NewJobListUseCase(jobRepo, cfg.Server.BaseURL)
+	jobLifecycleUseCase := usecase.NewJobLifecycleUseCase(jobRepo, storageRepo, queueRepo, tusUseCase, logger)
+	statusUpdateUseCase := usecase.NewStatusUpdateUseCase(jobRepo, storageRepo, logger)
+	retentionUseCase := usecase.NewRetentionUseCase(jobRepo, storageRepo, jobLifecycleUseCase, retentionPolicy, logger)
 
 	// Handlers
 	uploadHandler := handler2.NewUploadHandler(uploadUseCase)
 	statusHandler := handler2.NewStatusHandler(statusUseCase)
 	downloadHandler := handler2.NewDownloadHandler(downloadUseCase)
+	artifactHandler := handler2.NewArtifactHandler(artifactUseCase)
+	sessionHandler := handler2.NewSessionHandler(sessionUseCase)
+	tusHandler := handler2.NewTusHandler(tusUseCase, cfg.Server.BaseURL, cfg.Server.ChunkTimeout)
+	authHandler := handler2.NewAuthHandler(tokenVerifier)
+	rateLimitHandler := handler2.NewRateLimitHandler(uploadRatePerUser, uploadRatePerIP)
+	quotaHandler := handler2.NewQuotaHandler(quotaUseCase)
+	jobHandler := handler2.NewJobHandler(jobListUseCase, jobLifecycleUseCase)
+	proxyHandler := handler2.NewProxyHandler(cfg.Server.TrustedProxies)
 
 	// Router
-	apiRouter := router.NewRouter(uploadHandler, statusHandler, downloadHandler)
+	apiRouter := router.NewRouter(uploadHandler, statusHandler, downloadHandler, artifactHandler, sessionHandler, tusHandler, authHandler, rateLimitHandler, quotaHandler, jobHandler, proxyHandler)
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -81,12 +162,33 @@ func runServer(cmd *cobra.Command, args []string) {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	defer stopConsumer()
+
+	go func() {
+		if err := statusConsumer.Consume(consumerCtx, statusUpdateUseCase.Execute); err != nil {
+			logrus.WithError(err).Error("Status consumer stopped")
+		}
+	}()
+
+	if cfg.Retention.LifecycleRules {
+		ctx, cancel := context.WithTimeout(consumerCtx, 30*time.Second)
+		if err := retentionUseCase.InstallLifecycle(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to install storage lifecycle rules")
+		}
+		cancel()
+	}
+	if retentionPolicy.Enabled() {
+		go retentionUseCase.Run(consumerCtx, cfg.Retention.Interval)
+	}
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
 
 		logrus.Info("Shutting down server...")
+		stopConsumer()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -102,6 +204,32 @@ func runServer(cmd *cobra.Command, args []string) {
 	}
 }
 
+func newJobRepository(cfg config.DatabaseConfig) (repository.JobRepository, func(), error) {
+	switch cfg.Driver {
+	case persistence.DriverMemory:
+		return persistence.NewMemoryJobRepository(), func() {}, nil
+	case persistence.DriverPostgres, persistence.DriverSQLite:
+		dsn := cfg.DSN
+		if dsn == "" && cfg.Driver == persistence.DriverSQLite {
+			dsn = "file:airlance.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		}
+		if dsn == "" {
+			return nil, nil, fmt.Errorf("DATABASE_URL is required for job store %q", cfg.Driver)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		db, err := persistence.OpenDatabase(ctx, cfg.Driver, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return persistence.NewSQLJobRepository(db, cfg.Driver), func() { db.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown job store %q (expected postgres, sqlite or memory)", cfg.Driver)
+	}
+}
+
 func setupLogger() *logrus.Logger {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.TextFormatter{
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestPath string
+	assetsDir    string
+)
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Upload a timeline manifest with its assets",
+	Long:  `Upload a timeline manifest and every image, clip and audio track it references. Assets are looked up next to the manifest unless --assets is given.`,
+	Run:   runTimeline,
+}
+
+func init() {
+	timelineCmd.Flags().StringVarP(&manifestPath, "file", "f", "", "Path to the timeline manifest JSON (required)")
+	timelineCmd.Flags().StringVarP(&assetsDir, "assets", "d", "", "Directory holding the assets (defaults to the manifest's directory)")
+	timelineCmd.Flags().StringVarP(&apiURL, "url", "u", "http://localhost:8080", "API URL")
+	timelineCmd.Flags().StringVarP(&apiToken, "token", "t", "", "Access token from supabase-auth (default $AIRLANCE_TOKEN)")
+	timelineCmd.Flags().StringVarP(&preset, "preset", "p", "", "Output preset: h264-mp4, h265-mp4, vp9-webm, av1-mkv")
+	timelineCmd.Flags().StringVarP(&output, "output", "o", "", "Output mode: file, hls, hls+dash")
+	timelineCmd.Flags().BoolVar(&loudnorm, "loudnorm", false, "Normalize the loudness of the mix (EBU R128)")
+	timelineCmd.Flags().Float64Var(&loudnormTarget, "loudnorm-target", 0, "Integrated loudness target in LUFS (worker default when unset)")
+	timelineCmd.Flags().Float64Var(&loudnormTruePeak, "loudnorm-true-peak", 0, "True peak ceiling in dBTP (worker default when unset)")
+	timelineCmd.MarkFlagRequired("file")
+}
+
+func runTimeline(cmd *cobra.Command, args []string) {
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to read manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	var timeline entity.Timeline
+	if err := json.Unmarshal(manifest, &timeline); err != nil {
+		fmt.Printf("❌ Invalid manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	if assetsDir == "" {
+		assetsDir = filepath.Dir(manifestPath)
+	}
+
+	var assets []string
+	seen := make(map[string]bool)
+	for _, item := range timeline.Items {
+		assets = append(assets, item.Asset)
+	}
+	assets = append(assets, timeline.AudioAssets()...)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("manifest", string(manifest))
+
+	for _, name := range assets {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		file, err := os.Open(filepath.Join(assetsDir, name))
+		if err != nil {
+			fmt.Printf("❌ Asset not found: %v\n", err)
+			os.Exit(1)
+		}
+
+		part, err := writer.CreateFormFile("assets", name)
+		if err != nil {
+			fmt.Printf("❌ Failed to create asset form: %v\n", err)
+			os.Exit(1)
+		}
+		io.Copy(part, file)
+		file.Close()
+	}
+
+	if preset != "" {
+		writer.WriteField("preset", preset)
+	}
+	if output != "" {
+		writer.WriteField("output", output)
+	}
+	if loudnorm {
+		writer.WriteField("loudnorm", "true")
+		if loudnormTarget != 0 {
+			writer.WriteField("loudnorm_target", strconv.FormatFloat(loudnormTarget, 'g', -1, 64))
+		}
+		if loudnormTruePeak != 0 {
+			writer.WriteField("loudnorm_true_peak", strconv.FormatFloat(loudnormTruePeak, 'g', -1, 64))
+		}
+	}
+
+	writer.Close()
+
+	fmt.Printf("📤 Uploading timeline with %d assets...\n", len(seen))
+	req, err := newAPIRequest("POST", apiURL+"/upload/timeline", body)
+	if err != nil {
+		fmt.Printf("❌ Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("❌ Failed to upload: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		fmt.Printf("❌ Upload failed: %s\n", string(bodyBytes))
+		os.Exit(1)
+	}
+
+	var uploadResp dto.UploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+		fmt.Printf("❌ Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Upload successful!\n")
+	fmt.Printf("📋 Job UUID: %s\n", uploadResp.UUID)
+	fmt.Printf("🔍 Check status: curl %s/status/%s\n", apiURL, uploadResp.UUID)
+
+	pollStatus(apiURL, uploadResp.UUID)
+}
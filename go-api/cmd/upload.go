@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/airlance/api/internal/application/dto"
@@ -19,6 +20,24 @@ var (
 	mediaPath string
 	audioPath string
 	apiURL    string
+	preset    string
+	output    string
+	motion    string
+
+	visualizer         string
+	visualizerColor    string
+	visualizerPosition string
+
+	subtitlesPath     string
+	subtitlesMode     string
+	subtitlesFontSize int
+	subtitlesColor    string
+
+	loudnorm         bool
+	loudnormTarget   float64
+	loudnormTruePeak float64
+
+	resumable bool
 )
 
 var uploadCmd = &cobra.Command{
@@ -32,6 +51,21 @@ func init() {
 	uploadCmd.Flags().StringVarP(&mediaPath, "media", "m", "", "Path to media file - image or video (required)")
 	uploadCmd.Flags().StringVarP(&audioPath, "audio", "a", "", "Path to audio file (required)")
 	uploadCmd.Flags().StringVarP(&apiURL, "url", "u", "http://localhost:8080", "API URL")
+	uploadCmd.Flags().StringVarP(&apiToken, "token", "t", "", "Access token from supabase-auth (default $AIRLANCE_TOKEN)")
+	uploadCmd.Flags().StringVarP(&preset, "preset", "p", "", "Output preset: h264-mp4, h265-mp4, vp9-webm, av1-mkv, audio-only-m4a")
+	uploadCmd.Flags().StringVarP(&output, "output", "o", "", "Output mode: file, hls, hls+dash")
+	uploadCmd.Flags().StringVarP(&motion, "motion", "z", "", "Motion for image uploads: none, zoom-in, zoom-out, pan-left, pan-right, random")
+	uploadCmd.Flags().StringVar(&visualizer, "visualizer", "", "Audio visualizer for image uploads: none, waveform, spectrum, circle")
+	uploadCmd.Flags().StringVar(&visualizerColor, "visualizer-color", "", "Visualizer color as #RRGGBB")
+	uploadCmd.Flags().StringVar(&visualizerPosition, "visualizer-position", "", "Visualizer position: top, center, bottom")
+	uploadCmd.Flags().StringVarP(&subtitlesPath, "subtitles", "s", "", "Path to a subtitle file (srt, vtt, ass)")
+	uploadCmd.Flags().StringVar(&subtitlesMode, "subtitles-mode", "", "Subtitles mode: burn (default) or soft")
+	uploadCmd.Flags().IntVar(&subtitlesFontSize, "subtitles-font-size", 0, "Font size for burned-in subtitles")
+	uploadCmd.Flags().StringVar(&subtitlesColor, "subtitles-color", "", "Text color for burned-in subtitles as #RRGGBB")
+	uploadCmd.Flags().BoolVar(&loudnorm, "loudnorm", false, "Normalize audio loudness (EBU R128)")
+	uploadCmd.Flags().Float64Var(&loudnormTarget, "loudnorm-target", 0, "Integrated loudness target in LUFS (worker default when unset)")
+	uploadCmd.Flags().Float64Var(&loudnormTruePeak, "loudnorm-true-peak", 0, "True peak ceiling in dBTP (worker default when unset)")
+	uploadCmd.Flags().BoolVar(&resumable, "resumable", true, "Upload through a resumable tus session (not used with --subtitles)")
 	uploadCmd.MarkFlagRequired("media")
 	uploadCmd.MarkFlagRequired("audio")
 }
@@ -46,6 +80,24 @@ func runUpload(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Sessions carry no subtitles, so those uploads go through the form.
+	if resumable && subtitlesPath == "" {
+		fmt.Println("📤 Uploading files...")
+		jobUUID, err := runResumableUpload()
+		if err != nil {
+			fmt.Printf("❌ Failed to upload: %v\n", err)
+			fmt.Println("🔁 Run the same command again to resume")
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Upload successful!\n")
+		fmt.Printf("📋 Job UUID: %s\n", jobUUID)
+		fmt.Printf("🔍 Check status: curl %s/status/%s\n", apiURL, jobUUID)
+
+		pollStatus(apiURL, jobUUID)
+		return
+	}
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
@@ -77,10 +129,56 @@ func runUpload(cmd *cobra.Command, args []string) {
 	}
 	io.Copy(audioPart, audioFile)
 
+	if subtitlesPath != "" {
+		subtitlesFile, err := os.Open(subtitlesPath)
+		if err != nil {
+			fmt.Printf("❌ Failed to open subtitles: %v\n", err)
+			os.Exit(1)
+		}
+		defer subtitlesFile.Close()
+
+		subtitlesPart, err := writer.CreateFormFile("subtitles", filepath.Base(subtitlesPath))
+		if err != nil {
+			fmt.Printf("❌ Failed to create subtitles form: %v\n", err)
+			os.Exit(1)
+		}
+		io.Copy(subtitlesPart, subtitlesFile)
+
+		writer.WriteField("subtitles_mode", subtitlesMode)
+		writer.WriteField("subtitles_color", subtitlesColor)
+		if subtitlesFontSize > 0 {
+			writer.WriteField("subtitles_font_size", strconv.Itoa(subtitlesFontSize))
+		}
+	}
+
+	if preset != "" {
+		writer.WriteField("preset", preset)
+	}
+	if output != "" {
+		writer.WriteField("output", output)
+	}
+	if motion != "" {
+		writer.WriteField("motion", motion)
+	}
+	if visualizer != "" {
+		writer.WriteField("visualizer", visualizer)
+		writer.WriteField("visualizer_color", visualizerColor)
+		writer.WriteField("visualizer_position", visualizerPosition)
+	}
+	if loudnorm {
+		writer.WriteField("loudnorm", "true")
+		if loudnormTarget != 0 {
+			writer.WriteField("loudnorm_target", strconv.FormatFloat(loudnormTarget, 'g', -1, 64))
+		}
+		if loudnormTruePeak != 0 {
+			writer.WriteField("loudnorm_true_peak", strconv.FormatFloat(loudnormTruePeak, 'g', -1, 64))
+		}
+	}
+
 	writer.Close()
 
 	fmt.Println("📤 Uploading files...")
-	req, err := http.NewRequest("POST", apiURL+"/upload", body)
+	req, err := newAPIRequest("POST", apiURL+"/upload", body)
 	if err != nil {
 		fmt.Printf("❌ Failed to create request: %v\n", err)
 		os.Exit(1)
@@ -127,7 +225,7 @@ func pollStatus(baseURL, uuid string) {
 			fmt.Println("⏰ Timeout waiting for processing")
 			return
 		case <-ticker.C:
-			resp, err := http.Get(fmt.Sprintf("%s/status/%s", baseURL, uuid))
+			resp, err := getAPI(fmt.Sprintf("%s/status/%s", baseURL, uuid))
 			if err != nil {
 				fmt.Printf("❌ Failed to check status: %v\n", err)
 				return
@@ -138,15 +236,28 @@ func pollStatus(baseURL, uuid string) {
 			resp.Body.Close()
 
 			if statusResp.Status == "ready" {
-				fmt.Printf("✅ Processing complete!\n")
+				fmt.Printf("\r✅ Processing complete!\n")
 				fmt.Printf("📥 Download: %s\n", statusResp.URL)
+				if statusResp.StreamURL != "" {
+					fmt.Printf("📺 HLS: %s\n", statusResp.StreamURL)
+				}
+				if statusResp.DashURL != "" {
+					fmt.Printf("📺 DASH: %s\n", statusResp.DashURL)
+				}
 				return
 			} else if statusResp.Status == "failed" {
-				fmt.Println("❌ Processing failed")
+				fmt.Print("\r")
+				if statusResp.FailureCode == "timeout" {
+					fmt.Printf("⏰ Processing timed out: %s\n", statusResp.Error)
+				} else if statusResp.Error != "" {
+					fmt.Printf("❌ Processing failed: %s\n", statusResp.Error)
+				} else {
+					fmt.Println("❌ Processing failed")
+				}
 				return
 			}
 
-			fmt.Print(".")
+			fmt.Printf("\r⏳ %5.1f%%", statusResp.Progress)
 		}
 	}
 }
@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type ArtifactResponse struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	Checksum     string    `json:"checksum,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+	URL          string    `json:"url"`
+}
+
+type ArtifactListResponse struct {
+	UUID      string             `json:"uuid"`
+	Artifacts []ArtifactResponse `json:"artifacts"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// JobListRequest holds the query parameters of a job listing.
+type JobListRequest struct {
+	// Statuses are matched by any; MediaType is image, video or audio.
+	Statuses      []string
+	MediaType     string
+	Owner         string
+	CreatedAfter  string
+	CreatedBefore string
+	// Sort is created_at or updated_at, descending with a leading "-".
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+type JobSummary struct {
+	UUID        string     `json:"uuid"`
+	Type        string     `json:"type"`
+	Owner       string     `json:"owner,omitempty"`
+	Status      string     `json:"status"`
+	Progress    float64    `json:"progress"`
+	Preset      string     `json:"preset"`
+	OutputMode  string     `json:"output"`
+	MediaType   string     `json:"media_type,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	FailureCode string     `json:"failure_code,omitempty"`
+	StatusURL   string     `json:"status_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// JobListResponse is a page of jobs; NextCursor fetches the next page and
+// is empty on the last one.
+type JobListResponse struct {
+	Jobs       []JobSummary `json:"jobs"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+// QuotaUsageResponse reports what the caller's jobs consume against their
+// quotas. Limits are omitted when unlimited.
+type QuotaUsageResponse struct {
+	Storage    StorageUsage    `json:"storage"`
+	Processing ProcessingUsage `json:"processing"`
+}
+
+type StorageUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	LimitBytes int64 `json:"limit_bytes,omitempty"`
+}
+
+// ProcessingUsage is the worker time spent in the current calendar month.
+type ProcessingUsage struct {
+	UsedMinutes  float64   `json:"used_minutes"`
+	LimitMinutes float64   `json:"limit_minutes,omitempty"`
+	PeriodStart  time.Time `json:"period_start"`
+	ResetsAt     time.Time `json:"resets_at"`
+}
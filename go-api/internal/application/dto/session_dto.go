@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+// UploadSessionRequest describes a job whose inputs the client uploads
+// directly to storage. Options mirror the multipart /upload form fields.
+type UploadSessionRequest struct {
+	Media              SessionFile `json:"media"`
+	Audio              SessionFile `json:"audio"`
+	Preset             string      `json:"preset"`
+	OutputMode         string      `json:"output"`
+	Motion             string      `json:"motion"`
+	Visualizer         string      `json:"visualizer"`
+	VisualizerColor    string      `json:"visualizer_color"`
+	VisualizerPosition string      `json:"visualizer_position"`
+	Loudnorm           bool        `json:"loudnorm"`
+	LoudnormTarget     float64     `json:"loudnorm_target"`
+	LoudnormTruePeak   float64     `json:"loudnorm_true_peak"`
+	// Transport is "presigned" (default) or "tus" for resumable uploads
+	// through the API's tus endpoint.
+	Transport string `json:"transport"`
+}
+
+type SessionFile struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+type UploadSessionResponse struct {
+	UUID      string    `json:"uuid"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CommitURL string    `json:"commit_url"`
+	// TusURL is the creation endpoint of tus sessions; the job is committed
+	// automatically once both uploads finish.
+	TusURL string          `json:"tus_url,omitempty"`
+	Media  PresignedUpload `json:"media"`
+	Audio  PresignedUpload `json:"audio"`
+}
+
+// PresignedUpload tells the client where to PUT one input: either the whole
+// file to URL, or each part_size chunk to its part URL followed by a commit
+// listing the returned ETags.
+type PresignedUpload struct {
+	Object   string          `json:"object"`
+	URL      string          `json:"url,omitempty"`
+	UploadID string          `json:"upload_id,omitempty"`
+	PartSize int64           `json:"part_size,omitempty"`
+	Parts    []PresignedPart `json:"parts,omitempty"`
+}
+
+type PresignedPart struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// CommitRequest completes the multipart uploads of a session. Inputs that
+// were uploaded with a single PUT are omitted.
+type CommitRequest struct {
+	Media *CommitMultipart `json:"media,omitempty"`
+	Audio *CommitMultipart `json:"audio,omitempty"`
+}
+
+type CommitMultipart struct {
+	UploadID string       `json:"upload_id"`
+	Parts    []CommitPart `json:"parts"`
+}
+
+type CommitPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
@@ -1,7 +1,19 @@
 package dto
 
+import "github.com/airlance/api/internal/domain/entity"
+
 type StatusResponse struct {
-	UUID   string `json:"uuid"`
-	Status string `json:"status"`
-	URL    string `json:"url,omitempty"`
+	UUID        string  `json:"uuid"`
+	Status      string  `json:"status"`
+	Progress    float64 `json:"progress"`
+	Error       string  `json:"error,omitempty"`
+	FailureCode string  `json:"failure_code,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	StreamURL   string  `json:"stream_url,omitempty"`
+	DashURL     string  `json:"dash_url,omitempty"`
+	// Loudness is reported for ready jobs that requested loudness normalization.
+	Loudness *entity.LoudnessStats `json:"loudness,omitempty"`
+	// Artifacts maps artifact names (poster, sprites, thumbnails, preview) to
+	// their download URLs.
+	Artifacts map[string]string `json:"artifacts,omitempty"`
 }
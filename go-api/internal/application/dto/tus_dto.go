@@ -0,0 +1,14 @@
+package dto
+
+// TusUpload is the state of a resumable upload as reported in tus headers.
+type TusUpload struct {
+	ID     string
+	Offset int64
+	Length int64
+}
+
+// TusChecksum is a parsed Upload-Checksum header.
+type TusChecksum struct {
+	Algorithm string
+	Sum       []byte
+}
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"io"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
 type UploadRequest struct {
 	MediaFilename    string
 	MediaSize        int64
@@ -7,8 +13,44 @@ type UploadRequest struct {
 	AudioFilename    string
 	AudioSize        int64
 	AudioContentType string
+	Preset           string
+	OutputMode       string
+	Motion           string
+	Visualizer       entity.Visualizer
+	// Subtitles is nil when no subtitle file was uploaded.
+	Subtitles         *UploadFile
+	SubtitlesMode     string
+	SubtitlesFontSize int
+	SubtitlesColor    string
+	Loudnorm          entity.Loudnorm
+}
+
+// TimelineUploadRequest carries a timeline manifest and the assets it references.
+type TimelineUploadRequest struct {
+	Manifest   []byte
+	Assets     []UploadFile
+	Preset     string
+	OutputMode string
+	Loudnorm   entity.Loudnorm
+}
+
+type UploadFile struct {
+	Filename    string
+	Size        int64
+	ContentType string
+	Reader      FileReader
+}
+
+// FileReader is an uploaded file. Its headers are inspected with ReadAt
+// before it is streamed to storage with Read.
+type FileReader interface {
+	io.Reader
+	io.ReaderAt
 }
 
 type UploadResponse struct {
 	UUID string `json:"uuid"`
+	// Media and Assets describe the inputs as probed at upload.
+	Media  *entity.MediaInfo            `json:"media,omitempty"`
+	Assets map[string]*entity.MediaInfo `json:"assets,omitempty"`
 }
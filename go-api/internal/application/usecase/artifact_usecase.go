@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+// ArtifactUseCase lists and serves every object stored under a job's prefix:
+// the uploaded inputs, the output, previews and stream files.
+type ArtifactUseCase struct {
+	jobRepo     repository.JobRepository
+	storageRepo repository.StorageRepository
+	baseURL     string
+}
+
+func NewArtifactUseCase(jobRepo repository.JobRepository, storageRepo repository.StorageRepository, baseURL string) *ArtifactUseCase {
+	return &ArtifactUseCase{
+		jobRepo:     jobRepo,
+		storageRepo: storageRepo,
+		baseURL:     baseURL,
+	}
+}
+
+func (uc *ArtifactUseCase) List(ctx context.Context, jobUUID string) (*dto.ArtifactListResponse, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	prefix := job.UUID + "/"
+	objects, err := uc.storageRepo.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	resp := &dto.ArtifactListResponse{
+		UUID:      job.UUID,
+		Artifacts: make([]dto.ArtifactResponse, 0, len(objects)),
+	}
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Name, prefix)
+		resp.Artifacts = append(resp.Artifacts, dto.ArtifactResponse{
+			Name:         name,
+			Size:         obj.Size,
+			ContentType:  artifactContentType(name, obj.ContentType),
+			Checksum:     obj.Checksum,
+			ETag:         obj.ETag,
+			LastModified: obj.LastModified,
+			URL:          fmt.Sprintf("%s/jobs/%s/artifacts/%s", uc.baseURL, job.UUID, name),
+		})
+	}
+
+	return resp, nil
+}
+
+// Download opens an artifact by its name relative to the job prefix.
+func (uc *ArtifactUseCase) Download(ctx context.Context, jobUUID, name string) (*DownloadResult, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	clean := path.Clean("/" + name)
+	if name == "" || strings.Contains(name, "..") || clean == "/" {
+		return nil, fmt.Errorf("artifact not found: %s", name)
+	}
+
+	obj, err := uc.storageRepo.Download(ctx, job.UUID+clean)
+	if err != nil {
+		return nil, fmt.Errorf("artifact not found: %w", err)
+	}
+
+	return newDownloadResult(obj, path.Base(clean), artifactContentType(clean, obj.Info.ContentType)), nil
+}
+
+// artifactContentType falls back to the file extension when storage has no
+// useful content type.
+func artifactContentType(name, stored string) string {
+	if stored != "" && stored != "application/octet-stream" {
+		return stored
+	}
+	if contentType := entity.StreamContentType(name); contentType != "application/octet-stream" {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -4,40 +4,154 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"path/filepath"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/domain/entity"
 
 	"github.com/airlance/api/internal/domain/repository"
 )
 
 type DownloadUseCase struct {
-	storageRepo repository.StorageRepository
+	jobRepo       repository.JobRepository
+	storageRepo   repository.StorageRepository
+	streamSigner  repository.StreamSigner
+	presignExpiry time.Duration
 }
 
-func NewDownloadUseCase(storageRepo repository.StorageRepository) *DownloadUseCase {
+func NewDownloadUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	streamSigner repository.StreamSigner,
+	presignExpiry time.Duration,
+) *DownloadUseCase {
 	return &DownloadUseCase{
-		storageRepo: storageRepo,
+		jobRepo:       jobRepo,
+		storageRepo:   storageRepo,
+		streamSigner:  streamSigner,
+		presignExpiry: presignExpiry,
 	}
 }
 
 type DownloadResult struct {
-	Reader      io.ReadCloser
-	Size        int64
-	ContentType string
-	Filename    string
+	Reader       io.ReadSeekCloser
+	Size         int64
+	ContentType  string
+	Filename     string
+	ETag         string
+	Checksum     string
+	LastModified time.Time
+}
+
+func newDownloadResult(obj *repository.Object, filename, contentType string) *DownloadResult {
+	return &DownloadResult{
+		Reader:       obj,
+		Size:         obj.Info.Size,
+		ContentType:  contentType,
+		Filename:     filename,
+		ETag:         obj.Info.ETag,
+		Checksum:     obj.Info.Checksum,
+		LastModified: obj.Info.LastModified,
+	}
 }
 
-func (uc *DownloadUseCase) Execute(ctx context.Context, jobUUID string) (*DownloadResult, error) {
-	outputPath := filepath.Join(jobUUID, "output.mp4")
+func (uc *DownloadUseCase) Execute(ctx context.Context, jobUUID, filename string) (*DownloadResult, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	objectPath, fallbackType, err := downloadObject(job, filename)
+	if err != nil {
+		return nil, err
+	}
 
-	reader, size, contentType, err := uc.storageRepo.Download(ctx, outputPath)
+	obj, err := uc.storageRepo.Download(ctx, objectPath)
 	if err != nil {
 		return nil, fmt.Errorf("file not found: %w", err)
 	}
 
-	return &DownloadResult{
-		Reader:      reader,
-		Size:        size,
-		ContentType: contentType,
-		Filename:    "output.mp4",
-	}, nil
+	contentType := obj.Info.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = fallbackType
+	}
+
+	return newDownloadResult(obj, filename, contentType), nil
+}
+
+// Presign returns a short-lived storage URL for a file Execute would serve,
+// so clients can download it without going through the API.
+func (uc *DownloadUseCase) Presign(ctx context.Context, jobUUID, filename string) (string, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return "", fmt.Errorf("job not found: %w", err)
+	}
+
+	objectPath, _, err := downloadObject(job, filename)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := uc.storageRepo.Stat(ctx, objectPath); err != nil {
+		return "", fmt.Errorf("file not found: %w", err)
+	}
+
+	return uc.storageRepo.PresignGet(ctx, objectPath, filename, uc.presignExpiry)
+}
+
+// downloadObject resolves a download file name to its storage object and
+// content type. Besides the output itself, any artifact the worker reported
+// (poster, sprites, preview) can be fetched by its file name.
+func downloadObject(job *entity.Job, filename string) (string, string, error) {
+	preset := job.OutputPreset()
+	if filename == preset.OutputName() {
+		return job.OutputPath(), preset.ContentType, nil
+	}
+
+	artifact, ok := job.Result.Artifact(filename)
+	if !ok {
+		return "", "", fmt.Errorf("file not found: %s", filename)
+	}
+	return job.ArtifactPath(artifact.File), artifact.ContentType, nil
+}
+
+// ExecuteStream serves a file of the packaged HLS/DASH stream, addressed by
+// its path relative to the job's stream directory.
+func (uc *DownloadUseCase) ExecuteStream(ctx context.Context, jobUUID, name string) (*DownloadResult, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	if !entity.IsStreamingMode(job.OutputMode) {
+		return nil, fmt.Errorf("job %s has no stream output", jobUUID)
+	}
+
+	clean := path.Clean("/" + name)
+	if name == "" || strings.Contains(name, "..") || clean == "/" {
+		return nil, fmt.Errorf("file not found: %s", name)
+	}
+
+	objectPath := job.UUID + "/" + entity.StreamDir + clean
+	obj, err := uc.storageRepo.Download(ctx, objectPath)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+
+	contentType := obj.Info.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = entity.StreamContentType(name)
+	}
+
+	return newDownloadResult(obj, path.Base(clean), contentType), nil
+}
+
+// ExecuteSignedStream serves a stream file like ExecuteStream to whoever
+// holds a valid stream token for the job, instead of an access token.
+func (uc *DownloadUseCase) ExecuteSignedStream(ctx context.Context, jobUUID, token, name string) (*DownloadResult, error) {
+	if err := uc.streamSigner.Verify(jobUUID, token, time.Now()); err != nil {
+		return nil, err
+	}
+	return uc.ExecuteStream(ctx, jobUUID, name)
 }
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+// loadJob returns a job the caller may access. Other users' jobs are
+// reported as not found, so their UUIDs are not confirmed to exist, and so
+// are deleted jobs.
+func loadJob(ctx context.Context, jobRepo repository.JobRepository, jobUUID string) (*entity.Job, error) {
+	job, err := jobRepo.GetByUUID(ctx, jobUUID)
+	if err != nil {
+		return nil, err
+	}
+	if job.DeletedAt != nil {
+		return nil, repository.ErrJobNotFound
+	}
+	if user, ok := entity.UserFromContext(ctx); ok && !user.CanAccess(job) {
+		return nil, repository.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// jobOwner is the user ID new jobs are bound to; empty when authentication
+// is disabled.
+func jobOwner(ctx context.Context) string {
+	if user, ok := entity.UserFromContext(ctx); ok {
+		return user.ID
+	}
+	return ""
+}
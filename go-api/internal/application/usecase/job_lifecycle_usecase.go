@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrJobFinished is returned when cancelling a job that already finished.
+var ErrJobFinished = errors.New("job already finished")
+
+// JobLifecycleUseCase cancels and deletes jobs on behalf of their owners.
+type JobLifecycleUseCase struct {
+	jobRepo     repository.JobRepository
+	storageRepo repository.StorageRepository
+	queueRepo   repository.QueueRepository
+	tusUseCase  *TusUseCase
+	logger      *logrus.Logger
+}
+
+func NewJobLifecycleUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	queueRepo repository.QueueRepository,
+	tusUseCase *TusUseCase,
+	logger *logrus.Logger,
+) *JobLifecycleUseCase {
+	return &JobLifecycleUseCase{
+		jobRepo:     jobRepo,
+		storageRepo: storageRepo,
+		queueRepo:   queueRepo,
+		tusUseCase:  tusUseCase,
+		logger:      logger,
+	}
+}
+
+// Cancel stops a job that has not finished yet. Queued and running jobs are
+// also cancelled on the workers.
+func (uc *JobLifecycleUseCase) Cancel(ctx context.Context, jobUUID string) (*dto.StatusResponse, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.Status.IsTerminal() {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobFinished, job.UUID, job.Status)
+	}
+
+	if err := uc.cancel(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return &dto.StatusResponse{
+		UUID:     job.UUID,
+		Status:   string(entity.JobStatusCancelled),
+		Progress: job.Progress,
+	}, nil
+}
+
+// Delete removes a job's inputs and outputs, cancelling it first if it is
+// still running. The job is reported as not found afterwards. Its cancel
+// marker is kept, since the job may still be waiting in the queue; the
+// expire-cancel-markers lifecycle rule removes it.
+func (uc *JobLifecycleUseCase) Delete(ctx context.Context, jobUUID string) error {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return fmt.Errorf("job not found: %w", err)
+	}
+
+	if !job.Status.IsTerminal() {
+		if err := uc.cancel(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	objects, err := uc.storageRepo.List(ctx, job.UUID+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list job objects: %w", err)
+	}
+	for _, obj := range objects {
+		if err := uc.storageRepo.Delete(ctx, obj.Name); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.Name, err)
+		}
+	}
+
+	if err := uc.jobRepo.MarkDeleted(ctx, job.UUID); err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"job_uuid": job.UUID,
+		"objects":  len(objects),
+	}).Info("Job deleted")
+
+	return nil
+}
+
+// cancel marks an unfinished job cancelled and stops whatever is still
+// working on it: unfinished tus uploads, or the workers. Queued jobs also
+// get a cancel marker in storage, so a worker that picks the job up after
+// the broadcast went out still skips it.
+func (uc *JobLifecycleUseCase) cancel(ctx context.Context, job *entity.Job) error {
+	queued := job.Status == entity.JobStatusPending || job.Status == entity.JobStatusProcessing
+	if queued {
+		if err := uc.storageRepo.Upload(ctx, bytes.NewReader(nil), job.CancelMarkerPath(), 0, "application/octet-stream"); err != nil {
+			return fmt.Errorf("failed to record cancel: %w", err)
+		}
+	}
+
+	err := uc.jobRepo.UpdateStatus(ctx, job.UUID, entity.JobStatusUpdate{
+		Status:   entity.JobStatusCancelled,
+		Progress: job.Progress,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+
+	log := uc.logger.WithField("job_uuid", job.UUID)
+
+	if job.Status == entity.JobStatusUploading {
+		if err := uc.tusUseCase.Terminate(ctx, job.UUID); err != nil {
+			log.WithError(err).Warn("Failed to terminate uploads of cancelled job")
+		}
+	}
+	if queued {
+		if err := uc.queueRepo.PublishCancel(ctx, job.UUID); err != nil {
+			return fmt.Errorf("failed to publish cancel: %w", err)
+		}
+	}
+
+	log.Info("Job cancelled")
+	return nil
+}
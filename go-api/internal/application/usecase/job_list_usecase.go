@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+const (
+	defaultJobListLimit = 20
+	maxJobListLimit     = 100
+)
+
+var (
+	// ErrInvalidJobQuery is returned for malformed listing parameters.
+	ErrInvalidJobQuery = errors.New("invalid job query")
+	// ErrForbidden is returned when a user asks for other users' jobs.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// JobListUseCase pages through the caller's jobs. Admins see every job and
+// may filter by owner.
+type JobListUseCase struct {
+	jobRepo repository.JobRepository
+	baseURL string
+}
+
+func NewJobListUseCase(jobRepo repository.JobRepository, baseURL string) *JobListUseCase {
+	return &JobListUseCase{
+		jobRepo: jobRepo,
+		baseURL: baseURL,
+	}
+}
+
+func (uc *JobListUseCase) List(ctx context.Context, req dto.JobListRequest) (*dto.JobListResponse, error) {
+	filter, err := uc.filter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// One job past the page tells whether there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+	jobs, err := uc.jobRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	resp := &dto.JobListResponse{Jobs: make([]dto.JobSummary, 0, min(len(jobs), limit))}
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		resp.NextCursor = encodeCursor(filter, jobs[limit-1])
+	}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, uc.summary(job))
+	}
+
+	return resp, nil
+}
+
+func (uc *JobListUseCase) filter(ctx context.Context, req dto.JobListRequest) (entity.JobFilter, error) {
+	filter := entity.JobFilter{Owner: req.Owner}
+
+	if user, ok := entity.UserFromContext(ctx); ok && !user.Admin {
+		if req.Owner != "" && req.Owner != user.ID {
+			return filter, fmt.Errorf("%w: only admins may list other users' jobs", ErrForbidden)
+		}
+		filter.Owner = user.ID
+	}
+
+	for _, status := range req.Statuses {
+		switch s := entity.JobStatus(status); s {
+		case entity.JobStatusUploading, entity.JobStatusPending, entity.JobStatusProcessing,
+			entity.JobStatusReady, entity.JobStatusFailed, entity.JobStatusCancelled:
+			filter.Statuses = append(filter.Statuses, s)
+		default:
+			return filter, fmt.Errorf("%w: unknown status %q", ErrInvalidJobQuery, status)
+		}
+	}
+
+	if req.MediaType != "" {
+		var mediaType entity.MediaType
+		if err := mediaType.UnmarshalText([]byte(req.MediaType)); err != nil {
+			return filter, fmt.Errorf("%w: %w", ErrInvalidJobQuery, err)
+		}
+		filter.MediaType = &mediaType
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseQueryTime("created_after", req.CreatedAfter); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseQueryTime("created_before", req.CreatedBefore); err != nil {
+		return filter, err
+	}
+
+	sort := strings.TrimPrefix(req.Sort, "-")
+	switch entity.JobSort(sort) {
+	case "", entity.JobSortCreatedAt:
+		filter.Sort = entity.JobSortCreatedAt
+	case entity.JobSortUpdatedAt:
+		filter.Sort = entity.JobSortUpdatedAt
+	default:
+		return filter, fmt.Errorf("%w: unknown sort %q (allowed: created_at, updated_at)", ErrInvalidJobQuery, sort)
+	}
+	// Newest first unless a field is named without a leading "-".
+	filter.Descending = req.Sort == "" || strings.HasPrefix(req.Sort, "-")
+
+	switch {
+	case req.Limit == 0:
+		filter.Limit = defaultJobListLimit
+	case req.Limit < 0 || req.Limit > maxJobListLimit:
+		return filter, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidJobQuery, maxJobListLimit)
+	default:
+		filter.Limit = req.Limit
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(filter, req.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+func (uc *JobListUseCase) summary(job *entity.Job) dto.JobSummary {
+	summary := dto.JobSummary{
+		UUID:        job.UUID,
+		Type:        string(job.Type),
+		Owner:       job.Owner,
+		Status:      string(job.Status),
+		Progress:    job.Progress,
+		Preset:      job.Preset,
+		OutputMode:  job.OutputMode,
+		Error:       job.ErrorMessage,
+		FailureCode: job.FailureCode,
+		StatusURL:   fmt.Sprintf("%s/status/%s", uc.baseURL, job.UUID),
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.MediaInfo != nil {
+		summary.MediaType = job.MediaInfo.Type.String()
+	}
+	return summary
+}
+
+func parseQueryTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time", ErrInvalidJobQuery, name)
+	}
+	return t, nil
+}
+
+// listCursor is the opaque next_cursor of a listing. It records the sort it
+// was issued for, so it cannot be replayed against a different order.
+type listCursor struct {
+	Sort string    `json:"s"`
+	Time time.Time `json:"t"`
+	UUID string    `json:"id"`
+}
+
+func encodeCursor(filter entity.JobFilter, last *entity.Job) string {
+	cursor := last.Cursor(filter.Sort)
+	data, _ := json.Marshal(listCursor{Sort: sortKey(filter), Time: cursor.Time.UTC(), UUID: cursor.UUID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(filter entity.JobFilter, value string) (*entity.JobCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.UUID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidJobQuery)
+	}
+	if cursor.Sort != sortKey(filter) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidJobQuery)
+	}
+	return &entity.JobCursor{Time: cursor.Time, UUID: cursor.UUID}, nil
+}
+
+func sortKey(filter entity.JobFilter) string {
+	if filter.Descending {
+		return "-" + string(filter.Sort)
+	}
+	return string(filter.Sort)
+}
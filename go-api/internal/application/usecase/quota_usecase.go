@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+// Codes of the limits that answer 429 Too Many Requests.
+const (
+	CodeRateLimited             = "rate_limited"
+	CodeStorageQuotaExceeded    = "storage_quota_exceeded"
+	CodeProcessingQuotaExceeded = "processing_quota_exceeded"
+)
+
+// QuotaError is returned when a user is over a limit. RetryAfter is zero
+// when waiting does not help, e.g. storage that must be freed first.
+type QuotaError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return e.Message
+}
+
+// QuotaUseCase tracks each user's storage and processing time and turns away
+// new jobs once either quota is used up. Zero limits are unlimited.
+type QuotaUseCase struct {
+	jobRepo         repository.JobRepository
+	storageLimit    int64
+	processingLimit time.Duration
+}
+
+func NewQuotaUseCase(jobRepo repository.JobRepository, storageLimit int64, processingLimit time.Duration) *QuotaUseCase {
+	return &QuotaUseCase{
+		jobRepo:         jobRepo,
+		storageLimit:    storageLimit,
+		processingLimit: processingLimit,
+	}
+}
+
+// Check admits a new job that stores incoming bytes of input. Admins and
+// unauthenticated requests are not limited.
+func (uc *QuotaUseCase) Check(ctx context.Context, incoming int64) error {
+	user, ok := entity.UserFromContext(ctx)
+	if !ok || user.Admin || (uc.storageLimit == 0 && uc.processingLimit == 0) {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	start, end := quotaPeriod(now)
+	usage, err := uc.jobRepo.Usage(ctx, user.ID, start)
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %w", err)
+	}
+
+	if uc.storageLimit > 0 && usage.StorageBytes+incoming > uc.storageLimit {
+		return &QuotaError{
+			Code: CodeStorageQuotaExceeded,
+			Message: fmt.Sprintf("storage quota exceeded: %d of %d bytes used, upload needs %d",
+				usage.StorageBytes, uc.storageLimit, incoming),
+		}
+	}
+	if uc.processingLimit > 0 && usage.ProcessingTime >= uc.processingLimit {
+		return &QuotaError{
+			Code: CodeProcessingQuotaExceeded,
+			Message: fmt.Sprintf("processing quota of %.0f minutes used up until %s",
+				uc.processingLimit.Minutes(), end.Format(time.RFC3339)),
+			RetryAfter: end.Sub(now),
+		}
+	}
+	return nil
+}
+
+// Usage reports the caller's consumption and limits.
+func (uc *QuotaUseCase) Usage(ctx context.Context) (*dto.QuotaUsageResponse, error) {
+	start, end := quotaPeriod(time.Now().UTC())
+	usage, err := uc.jobRepo.Usage(ctx, jobOwner(ctx), start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+
+	return &dto.QuotaUsageResponse{
+		Storage: dto.StorageUsage{
+			UsedBytes:  usage.StorageBytes,
+			LimitBytes: uc.storageLimit,
+		},
+		Processing: dto.ProcessingUsage{
+			UsedMinutes:  usage.ProcessingTime.Minutes(),
+			LimitMinutes: uc.processingLimit.Minutes(),
+			PeriodStart:  start,
+			ResetsAt:     end,
+		},
+	}, nil
+}
+
+// quotaPeriod returns the calendar month (UTC) processing time is counted in.
+func quotaPeriod(now time.Time) (start, end time.Time) {
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
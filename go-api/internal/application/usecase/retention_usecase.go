@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// retentionPageSize is how many jobs a sweep loads at a time.
+const retentionPageSize = 100
+
+// cancelMarkerDays is how long cancel markers are kept; long enough for any
+// queued message of the job to have been delivered.
+const cancelMarkerDays = 7
+
+// RetentionPolicy says how long job objects are kept. Zero durations keep
+// them forever.
+type RetentionPolicy struct {
+	// DeleteInputs removes the inputs of ready jobs, keeping their outputs.
+	DeleteInputs bool
+	// OutputsFor and FailedFor are how long ready, and failed or cancelled,
+	// jobs are kept after they finished before they are deleted.
+	OutputsFor time.Duration
+	FailedFor  time.Duration
+	// StaleUploadsAfter is how long a job may wait for its direct upload
+	// before it is deleted along with what was uploaded.
+	StaleUploadsAfter time.Duration
+}
+
+// Enabled reports whether the policy ever deletes anything.
+func (p RetentionPolicy) Enabled() bool {
+	return p.DeleteInputs || p.OutputsFor > 0 || p.FailedFor > 0 || p.StaleUploadsAfter > 0
+}
+
+// RetentionUseCase enforces the retention policy by sweeping the job store
+// for jobs whose objects are due for deletion.
+type RetentionUseCase struct {
+	jobRepo          repository.JobRepository
+	storageRepo      repository.StorageRepository
+	lifecycleUseCase *JobLifecycleUseCase
+	policy           RetentionPolicy
+	logger           *logrus.Logger
+}
+
+func NewRetentionUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	lifecycleUseCase *JobLifecycleUseCase,
+	policy RetentionPolicy,
+	logger *logrus.Logger,
+) *RetentionUseCase {
+	return &RetentionUseCase{
+		jobRepo:          jobRepo,
+		storageRepo:      storageRepo,
+		lifecycleUseCase: lifecycleUseCase,
+		policy:           policy,
+		logger:           logger,
+	}
+}
+
+// Run sweeps right away and then every interval until ctx is cancelled.
+func (uc *RetentionUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.Sweep(ctx); err != nil && ctx.Err() == nil {
+			uc.logger.WithError(err).Error("Retention sweep failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes every object the policy no longer keeps. Jobs that fail to
+// clean up are logged and retried on the next sweep.
+func (uc *RetentionUseCase) Sweep(ctx context.Context) error {
+	now := time.Now()
+	fields := logrus.Fields{}
+
+	if uc.policy.DeleteInputs {
+		filter := entity.JobFilter{
+			Statuses:   []entity.JobStatus{entity.JobStatusReady},
+			InputsKept: true,
+		}
+		n, err := uc.each(ctx, filter, uc.deleteInputs)
+		if err != nil {
+			return err
+		}
+		fields["inputs_deleted"] = n
+	}
+
+	if uc.policy.OutputsFor > 0 {
+		filter := entity.JobFilter{
+			Statuses:        []entity.JobStatus{entity.JobStatusReady},
+			CompletedBefore: now.Add(-uc.policy.OutputsFor),
+		}
+		n, err := uc.each(ctx, filter, uc.deleteJob)
+		if err != nil {
+			return err
+		}
+		fields["outputs_deleted"] = n
+	}
+
+	if uc.policy.FailedFor > 0 {
+		filter := entity.JobFilter{
+			Statuses:        []entity.JobStatus{entity.JobStatusFailed, entity.JobStatusCancelled},
+			CompletedBefore: now.Add(-uc.policy.FailedFor),
+		}
+		n, err := uc.each(ctx, filter, uc.deleteJob)
+		if err != nil {
+			return err
+		}
+		fields["failed_deleted"] = n
+	}
+
+	if uc.policy.StaleUploadsAfter > 0 {
+		filter := entity.JobFilter{
+			Statuses:      []entity.JobStatus{entity.JobStatusUploading},
+			CreatedBefore: now.Add(-uc.policy.StaleUploadsAfter),
+		}
+		n, err := uc.each(ctx, filter, uc.deleteJob)
+		if err != nil {
+			return err
+		}
+		fields["stale_uploads_deleted"] = n
+	}
+
+	uc.logger.WithFields(fields).Info("Retention sweep finished")
+	return nil
+}
+
+// InstallLifecycle has storage expire cancel markers, and when stale
+// uploads expire, the state of unfinished tus uploads and unfinished
+// multipart uploads, so none are left behind by jobs the sweeper never
+// sees. Other lifecycle rules of the bucket are kept.
+func (uc *RetentionUseCase) InstallLifecycle(ctx context.Context) error {
+	rules := []repository.LifecycleRule{
+		{ID: "expire-cancel-markers", Prefix: entity.CancelMarkerDir, Days: cancelMarkerDays},
+	}
+
+	if uc.policy.StaleUploadsAfter > 0 {
+		// Lifecycle rules count whole days; round up so no upload expires
+		// before the sweeper considers it stale.
+		days := int((uc.policy.StaleUploadsAfter + 24*time.Hour - 1) / (24 * time.Hour))
+		rules = append(rules,
+			repository.LifecycleRule{ID: "expire-tus-uploads", Prefix: tusPrefix, Days: days},
+			repository.LifecycleRule{ID: "abort-incomplete-uploads", AbortUploadsDays: days},
+		)
+	}
+	if err := uc.storageRepo.SetLifecycle(ctx, rules); err != nil {
+		return fmt.Errorf("failed to install lifecycle rules: %w", err)
+	}
+
+	uc.logger.WithField("rules", len(rules)).Info("Storage lifecycle rules installed")
+	return nil
+}
+
+// each calls fn for every job matching the filter and returns how many
+// calls succeeded. Jobs fn fails on are skipped.
+func (uc *RetentionUseCase) each(ctx context.Context, filter entity.JobFilter, fn func(context.Context, *entity.Job) error) (int, error) {
+	filter.Sort = entity.JobSortCreatedAt
+	filter.Limit = retentionPageSize
+
+	done := 0
+	for {
+		jobs, err := uc.jobRepo.List(ctx, filter)
+		if err != nil {
+			return done, fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		for _, job := range jobs {
+			if err := fn(ctx, job); err != nil {
+				if ctx.Err() != nil {
+					return done, ctx.Err()
+				}
+				uc.logger.WithError(err).WithField("job_uuid", job.UUID).Warn("Failed to apply retention")
+				continue
+			}
+			done++
+		}
+
+		if len(jobs) < filter.Limit {
+			return done, nil
+		}
+		cursor := jobs[len(jobs)-1].Cursor(filter.Sort)
+		filter.After = &cursor
+	}
+}
+
+func (uc *RetentionUseCase) deleteJob(ctx context.Context, job *entity.Job) error {
+	return uc.lifecycleUseCase.Delete(ctx, job.UUID)
+}
+
+// deleteInputs removes what was uploaded for a ready job and records the
+// storage its outputs still take.
+func (uc *RetentionUseCase) deleteInputs(ctx context.Context, job *entity.Job) error {
+	inputs := []string{job.MediaPath, job.AudioPath, job.Subtitles.Path}
+	if job.Type == entity.JobTypeTimeline {
+		assets, err := uc.storageRepo.List(ctx, job.UUID+"/"+entity.TimelineAssetDir+"/")
+		if err != nil {
+			return fmt.Errorf("failed to list assets: %w", err)
+		}
+		for _, asset := range assets {
+			inputs = append(inputs, asset.Name)
+		}
+	}
+
+	for _, object := range inputs {
+		if object == "" {
+			continue
+		}
+		if err := uc.storageRepo.Delete(ctx, object); err != nil && !errors.Is(err, repository.ErrObjectNotFound) {
+			return fmt.Errorf("failed to delete %s: %w", object, err)
+		}
+	}
+
+	objects, err := uc.storageRepo.List(ctx, job.UUID+"/")
+	if err != nil {
+		return fmt.Errorf("failed to measure job storage: %w", err)
+	}
+	var size int64
+	for _, obj := range objects {
+		size += obj.Size
+	}
+
+	if err := uc.jobRepo.MarkInputsDeleted(ctx, job.UUID, size); err != nil {
+		return fmt.Errorf("failed to mark inputs deleted: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type StatusUpdateUseCase struct {
+	jobRepo     repository.JobRepository
+	storageRepo repository.StorageRepository
+	logger      *logrus.Logger
+}
+
+func NewStatusUpdateUseCase(jobRepo repository.JobRepository, storageRepo repository.StorageRepository, logger *logrus.Logger) *StatusUpdateUseCase {
+	return &StatusUpdateUseCase{
+		jobRepo:     jobRepo,
+		storageRepo: storageRepo,
+		logger:      logger,
+	}
+}
+
+// Execute applies a status event reported by a worker to the stored job.
+func (uc *StatusUpdateUseCase) Execute(ctx context.Context, jobUUID string, update entity.JobStatusUpdate) error {
+	log := uc.logger.WithFields(logrus.Fields{
+		"job_uuid": jobUUID,
+		"status":   update.Status,
+		"worker":   update.WorkerID,
+	})
+
+	switch update.Status {
+	case entity.JobStatusProcessing, entity.JobStatusReady, entity.JobStatusFailed, entity.JobStatusCancelled:
+	default:
+		log.Warn("Status event with unknown status ignored")
+		return nil
+	}
+
+	// A worker that picked the job up before it was cancelled may still
+	// finish it; the user asked to stop, so its result is dropped. Events
+	// can also arrive out of order, and a finished job never goes back to
+	// processing. Both are checked in the update itself, so a cancel landing
+	// between reading and writing the job is not overwritten.
+	from := []entity.JobStatus{entity.JobStatusPending, entity.JobStatusProcessing}
+	if update.Status.IsTerminal() {
+		from = append(from, entity.JobStatusReady, entity.JobStatusFailed)
+	}
+
+	// Finished jobs are measured once their outputs are stored, so storage
+	// quotas count outputs as well as inputs.
+	if update.Status.IsTerminal() {
+		objects, err := uc.storageRepo.List(ctx, jobUUID+"/")
+		if err != nil {
+			log.WithError(err).Warn("Failed to measure job storage")
+		} else {
+			var size int64
+			for _, obj := range objects {
+				size += obj.Size
+			}
+			update.StorageBytes = &size
+		}
+	}
+
+	updated, err := uc.jobRepo.TransitionStatus(ctx, jobUUID, from, update)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+	if !updated {
+		log.Debug("Status event for cancelled, deleted or finished job ignored")
+		return nil
+	}
+
+	if update.Status == entity.JobStatusFailed {
+		log.WithField("reason", update.ErrorMessage).Warn("Job failed")
+	} else {
+		log.Debug("Job status updated")
+	}
+
+	return nil
+}
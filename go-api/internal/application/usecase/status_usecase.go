@@ -3,7 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"time"
 
 	"github.com/airlance/api/internal/application/dto"
 	"github.com/airlance/api/internal/domain/entity"
@@ -11,39 +11,78 @@ import (
 )
 
 type StatusUseCase struct {
-	jobRepo     repository.JobRepository
-	storageRepo repository.StorageRepository
-	baseURL     string
+	jobRepo      repository.JobRepository
+	storageRepo  repository.StorageRepository
+	streamSigner repository.StreamSigner
+	streamExpiry time.Duration
+	baseURL      string
 }
 
-func NewStatusUseCase(jobRepo repository.JobRepository, storageRepo repository.StorageRepository, baseURL string) *StatusUseCase {
+func NewStatusUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	streamSigner repository.StreamSigner,
+	streamExpiry time.Duration,
+	baseURL string,
+) *StatusUseCase {
 	return &StatusUseCase{
-		jobRepo:     jobRepo,
-		storageRepo: storageRepo,
-		baseURL:     baseURL,
+		jobRepo:      jobRepo,
+		storageRepo:  storageRepo,
+		streamSigner: streamSigner,
+		streamExpiry: streamExpiry,
+		baseURL:      baseURL,
 	}
 }
 
 func (uc *StatusUseCase) Execute(ctx context.Context, jobUUID string) (*dto.StatusResponse, error) {
-	job, err := uc.jobRepo.GetByUUID(ctx, jobUUID)
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
 	if err != nil {
 		return nil, fmt.Errorf("job not found: %w", err)
 	}
 
-	outputPath := filepath.Join(jobUUID, "output.mp4")
-	exists, err := uc.storageRepo.Exists(ctx, outputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check output: %w", err)
+	resp := &dto.StatusResponse{
+		UUID:        jobUUID,
+		Status:      string(job.Status),
+		Progress:    job.Progress,
+		Error:       job.ErrorMessage,
+		FailureCode: job.FailureCode,
 	}
 
-	resp := &dto.StatusResponse{
-		UUID:   jobUUID,
-		Status: string(job.Status),
+	// Workers that predate status events never report completion, so fall
+	// back to probing storage for the output while the job looks unfinished.
+	if !job.Status.IsTerminal() {
+		exists, err := uc.storageRepo.Exists(ctx, job.OutputPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check output: %w", err)
+		}
+		if exists {
+			resp.Status = string(entity.JobStatusReady)
+		}
 	}
 
-	if exists {
-		resp.Status = string(entity.JobStatusReady)
-		resp.URL = fmt.Sprintf("%s/download/%s/output.mp4", uc.baseURL, jobUUID)
+	if resp.Status == string(entity.JobStatusReady) {
+		resp.Progress = 100
+		resp.URL = fmt.Sprintf("%s/download/%s/%s", uc.baseURL, jobUUID, job.OutputPreset().OutputName())
+
+		// Players resolve playlist entries against the playlist URL, so a
+		// token in the path reaches every segment.
+		token := uc.streamSigner.Sign(jobUUID, time.Now().Add(uc.streamExpiry))
+		streamBase := fmt.Sprintf("%s/stream/%s/%s", uc.baseURL, jobUUID, token)
+		if entity.IsStreamingMode(job.OutputMode) {
+			resp.StreamURL = streamBase + "/" + entity.HLSMasterPlaylist
+		}
+		if job.OutputMode == entity.OutputModeHLSDASH {
+			resp.DashURL = streamBase + "/" + entity.DASHManifest
+		}
+		if job.Result != nil {
+			resp.Loudness = job.Result.Loudness
+			if len(job.Result.Artifacts) > 0 {
+				resp.Artifacts = make(map[string]string, len(job.Result.Artifacts))
+			}
+			for _, artifact := range job.Result.Artifacts {
+				resp.Artifacts[artifact.Name] = fmt.Sprintf("%s/download/%s/%s", uc.baseURL, jobUUID, artifact.File)
+			}
+		}
 	}
 
 	return resp, nil
@@ -0,0 +1,557 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/domain/service"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TusVersion    = "1.0.0"
+	TusExtensions = "creation,termination,checksum"
+	TusMaxSize    = 5 << 40 // largest object a multipart upload can assemble
+
+	// tusPrefix holds the state of unfinished uploads, outside any job prefix
+	// so it never shows up among a job's artifacts.
+	tusPrefix = ".tus/"
+)
+
+var (
+	ErrUploadNotFound      = errors.New("upload not found")
+	ErrOffsetMismatch      = errors.New("upload offset mismatch")
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+	ErrMalformedChecksum   = errors.New("malformed checksum")
+	ErrUploadTooLarge      = errors.New("upload exceeds its length")
+)
+
+// TusChecksumAlgorithms lists the Upload-Checksum algorithms accepted by Patch.
+var TusChecksumAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"md5":    md5.New,
+}
+
+// tusState is stored next to an unfinished upload. Data received past the
+// last full part is kept in a separate pending object until a part fills up,
+// since storage only accepts small parts at the end of an upload.
+type tusState struct {
+	UploadID string `json:"upload_id"`
+	Object   string `json:"object"`
+	Length   int64  `json:"length"`
+	PartSize int64  `json:"part_size"`
+}
+
+// TusUseCase implements the tus resumable upload protocol for the inputs of
+// an upload session, on top of storage multipart uploads. Once both inputs
+// are complete the session is committed.
+type TusUseCase struct {
+	jobRepo        repository.JobRepository
+	storageRepo    repository.StorageRepository
+	sessionUseCase *UploadSessionUseCase
+	validationSvc  *service.ValidationService
+	logger         *logrus.Logger
+	locks          keyedMutex
+}
+
+func NewTusUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	sessionUseCase *UploadSessionUseCase,
+	validationSvc *service.ValidationService,
+	logger *logrus.Logger,
+) *TusUseCase {
+	return &TusUseCase{
+		jobRepo:        jobRepo,
+		storageRepo:    storageRepo,
+		sessionUseCase: sessionUseCase,
+		validationSvc:  validationSvc,
+		logger:         logger,
+		locks:          keyedMutex{locks: make(map[string]*keyedLock)},
+	}
+}
+
+// Create starts the upload of one session input. The metadata names the job
+// ("job"), the input ("media" or "audio") and optionally its "filetype".
+// Creating an input again discards the data uploaded so far.
+func (uc *TusUseCase) Create(ctx context.Context, length int64, metadata map[string]string) (*dto.TusUpload, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "upload_length", Code: service.CodeInvalidRequest,
+			Message: "upload length must be positive"})
+	}
+	if length > TusMaxSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	job, err := loadJob(ctx, uc.jobRepo, metadata["job"])
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.Status != entity.JobStatusUploading {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotUploading, job.UUID, job.Status)
+	}
+
+	input := metadata["input"]
+	object, err := inputObject(job, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validationSvc.ValidateSize(input, path.Base(object), length); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	id := job.UUID + "." + input
+
+	unlock := uc.locks.lock(id)
+	defer unlock()
+
+	log := uc.logger.WithFields(logrus.Fields{"job_uuid": job.UUID, "input": input})
+
+	if previous, err := uc.loadState(ctx, id); err == nil {
+		if err := uc.discard(ctx, id, previous); err != nil {
+			log.WithError(err).Warn("Failed to discard previous upload")
+		}
+	}
+
+	uploadID, err := uc.storageRepo.CreateMultipartUpload(ctx, object, metadata["filetype"])
+	if err != nil {
+		log.WithError(err).Error("Failed to create multipart upload")
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	state := &tusState{UploadID: uploadID, Object: object, Length: length, PartSize: partSize(length)}
+	if err := uc.saveState(ctx, id, state); err != nil {
+		uc.storageRepo.AbortMultipartUpload(ctx, object, uploadID)
+		return nil, fmt.Errorf("failed to save upload state: %w", err)
+	}
+
+	log.WithField("length", length).Info("Tus upload created")
+
+	return &dto.TusUpload{ID: id, Length: length}, nil
+}
+
+// Head reports how much of an upload has been received.
+func (uc *TusUseCase) Head(ctx context.Context, id string) (*dto.TusUpload, error) {
+	if err := uc.authorize(ctx, id); err != nil {
+		return nil, err
+	}
+
+	state, err := uc.loadState(ctx, id)
+	if errors.Is(err, ErrUploadNotFound) {
+		return uc.finished(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	offset, _, err := uc.offset(ctx, id, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TusUpload{ID: id, Offset: offset, Length: state.Length}, nil
+}
+
+// finished reports a completed upload, whose state is gone but whose object
+// is in place.
+func (uc *TusUseCase) finished(ctx context.Context, id string) (*dto.TusUpload, error) {
+	jobUUID, input, err := parseUploadID(id)
+	if err != nil {
+		return nil, err
+	}
+	job, err := uc.jobRepo.GetByUUID(ctx, jobUUID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	object, err := inputObject(job, input)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	info, err := uc.storageRepo.Stat(ctx, object)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+
+	return &dto.TusUpload{ID: id, Offset: info.Size, Length: info.Size}, nil
+}
+
+// Patch appends body to the upload at offset. With a checksum the data is
+// only kept if it matches; without one, data received before the body broke
+// off is kept so the client can resume from there.
+func (uc *TusUseCase) Patch(ctx context.Context, id string, offset int64, checksum *dto.TusChecksum, body io.Reader) (*dto.TusUpload, error) {
+	var h hash.Hash
+	if checksum != nil {
+		newHash, ok := TusChecksumAlgorithms[checksum.Algorithm]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedChecksum, checksum.Algorithm)
+		}
+		h = newHash()
+		if len(checksum.Sum) != h.Size() {
+			return nil, fmt.Errorf("%w: %s digest of %d bytes", ErrMalformedChecksum, checksum.Algorithm, len(checksum.Sum))
+		}
+	}
+	if err := uc.authorize(ctx, id); err != nil {
+		return nil, err
+	}
+
+	unlock := uc.locks.lock(id)
+	defer unlock()
+
+	state, err := uc.loadState(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, parts, err := uc.offset(ctx, id, state)
+	if err != nil {
+		return nil, err
+	}
+	if offset != current {
+		return nil, fmt.Errorf("%w: upload is at %d, got %d", ErrOffsetMismatch, current, offset)
+	}
+
+	log := uc.logger.WithFields(logrus.Fields{"upload_id": id, "offset": offset})
+
+	spool, err := os.CreateTemp("", "tus-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	uploaded := sumPartSizes(parts)
+	pending := current - uploaded
+	if pending > 0 {
+		obj, err := uc.storageRepo.Download(ctx, pendingObject(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending data: %w", err)
+		}
+		_, err = io.Copy(spool, obj)
+		obj.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending data: %w", err)
+		}
+	}
+
+	var dst io.Writer = spool
+	if h != nil {
+		dst = io.MultiWriter(spool, h)
+	}
+	remaining := state.Length - current
+	received, readErr := io.Copy(dst, io.LimitReader(body, remaining+1))
+	if received > remaining {
+		return nil, ErrUploadTooLarge
+	}
+	if readErr != nil {
+		if h != nil || received == 0 {
+			return nil, fmt.Errorf("failed to read upload data: %w", readErr)
+		}
+		log.WithError(readErr).WithField("received", received).Warn("Upload interrupted, keeping received data")
+	}
+	if h != nil && !bytes.Equal(h.Sum(nil), checksum.Sum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	// The client may be gone by now; store what was received regardless.
+	ctx = context.WithoutCancel(ctx)
+
+	complete := current+received == state.Length
+	if received == 0 {
+		return &dto.TusUpload{ID: id, Offset: current, Length: state.Length}, nil
+	}
+	if err := uc.store(ctx, id, state, spool, pending+received, len(parts), complete); err != nil {
+		log.WithError(err).Error("Failed to store upload data")
+		return nil, err
+	}
+
+	upload := &dto.TusUpload{ID: id, Offset: current + received, Length: state.Length}
+	if complete {
+		if err := uc.complete(ctx, id, state); err != nil {
+			log.WithError(err).Error("Failed to complete upload")
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// store uploads the spooled data as full parts, plus a short final part when
+// the upload is complete, and keeps any remainder as the pending object.
+func (uc *TusUseCase) store(ctx context.Context, id string, state *tusState, spool *os.File, size int64, partCount int, complete bool) error {
+	var pos int64
+	for size-pos >= state.PartSize || (complete && pos < size) {
+		n := min(state.PartSize, size-pos)
+		partCount++
+		if _, err := uc.storageRepo.UploadPart(ctx, state.Object, state.UploadID, partCount, io.NewSectionReader(spool, pos, n), n); err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", partCount, err)
+		}
+		pos += n
+	}
+
+	if pos < size {
+		return uc.storageRepo.Upload(ctx, io.NewSectionReader(spool, pos, size-pos), pendingObject(id), size-pos, "application/octet-stream")
+	}
+	if pos > 0 {
+		if err := uc.storageRepo.Delete(ctx, pendingObject(id)); err != nil && !errors.Is(err, repository.ErrObjectNotFound) {
+			return fmt.Errorf("failed to delete pending data: %w", err)
+		}
+	}
+	return nil
+}
+
+// complete assembles the object and, once the other input is in place too,
+// commits the session in the background: inspecting the inputs can take
+// longer than the client waits for the last chunk. The job's status shows
+// how the commit went.
+func (uc *TusUseCase) complete(ctx context.Context, id string, state *tusState) error {
+	parts, err := uc.storageRepo.ListParts(ctx, state.Object, state.UploadID)
+	if err != nil {
+		return fmt.Errorf("failed to list parts: %w", err)
+	}
+	completed := make([]repository.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = repository.CompletedPart{Number: part.Number, ETag: part.ETag}
+	}
+	if err := uc.storageRepo.CompleteMultipartUpload(ctx, state.Object, state.UploadID, completed); err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	if err := uc.storageRepo.Delete(ctx, stateObject(id)); err != nil {
+		uc.logger.WithError(err).WithField("upload_id", id).Warn("Failed to delete upload state")
+	}
+
+	jobUUID, input, _ := parseUploadID(id)
+	uc.logger.WithFields(logrus.Fields{"job_uuid": jobUUID, "input": input}).Info("Tus upload completed")
+
+	go uc.commit(ctx, jobUUID, input)
+	return nil
+}
+
+// commit commits the job once the input other than the one just completed
+// is in storage. Both inputs may finish at once; whichever sees the other
+// one commits the job.
+func (uc *TusUseCase) commit(ctx context.Context, jobUUID, input string) {
+	log := uc.logger.WithFields(logrus.Fields{"job_uuid": jobUUID, "input": input})
+
+	unlock := uc.locks.lock(jobUUID)
+	defer unlock()
+
+	job, err := uc.jobRepo.GetByUUID(ctx, jobUUID)
+	if err != nil {
+		log.WithError(err).Error("Failed to load job of completed upload")
+		return
+	}
+	other := job.AudioPath
+	if input == "audio" {
+		other = job.MediaPath
+	}
+	if exists, err := uc.storageRepo.Exists(ctx, other); err != nil || !exists {
+		return
+	}
+
+	// Commit marks the job failed itself when an input is rejected; other
+	// failures leave it uploading for the client to commit again.
+	if _, err := uc.sessionUseCase.Commit(ctx, jobUUID, dto.CommitRequest{}); err != nil && !errors.Is(err, ErrJobNotUploading) {
+		log.WithError(err).Error("Failed to commit job")
+	}
+}
+
+// Delete terminates an unfinished upload and discards its data.
+func (uc *TusUseCase) Delete(ctx context.Context, id string) error {
+	if err := uc.authorize(ctx, id); err != nil {
+		return err
+	}
+
+	unlock := uc.locks.lock(id)
+	defer unlock()
+
+	state, err := uc.loadState(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.discard(ctx, id, state); err != nil {
+		return fmt.Errorf("failed to terminate upload: %w", err)
+	}
+
+	uc.logger.WithField("upload_id", id).Info("Tus upload terminated")
+	return nil
+}
+
+// Terminate discards the unfinished uploads of every input of a job. The
+// caller checks access to the job.
+func (uc *TusUseCase) Terminate(ctx context.Context, jobUUID string) error {
+	for _, input := range []string{"media", "audio"} {
+		id := jobUUID + "." + input
+
+		unlock := uc.locks.lock(id)
+		state, err := uc.loadState(ctx, id)
+		if err == nil {
+			err = uc.discard(ctx, id, state)
+		}
+		unlock()
+
+		if err != nil && !errors.Is(err, ErrUploadNotFound) {
+			return fmt.Errorf("failed to terminate %s upload: %w", input, err)
+		}
+	}
+	return nil
+}
+
+// authorize checks the caller may access the job an upload belongs to.
+func (uc *TusUseCase) authorize(ctx context.Context, id string) error {
+	jobUUID, _, err := parseUploadID(id)
+	if err != nil {
+		return err
+	}
+	if _, err := loadJob(ctx, uc.jobRepo, jobUUID); err != nil {
+		return ErrUploadNotFound
+	}
+	return nil
+}
+
+func (uc *TusUseCase) discard(ctx context.Context, id string, state *tusState) error {
+	if err := uc.storageRepo.AbortMultipartUpload(ctx, state.Object, state.UploadID); err != nil {
+		return err
+	}
+	if err := uc.storageRepo.Delete(ctx, pendingObject(id)); err != nil && !errors.Is(err, repository.ErrObjectNotFound) {
+		return err
+	}
+	return uc.storageRepo.Delete(ctx, stateObject(id))
+}
+
+// offset returns how many bytes of the upload are stored, in parts and in
+// the pending object, along with the uploaded parts.
+func (uc *TusUseCase) offset(ctx context.Context, id string, state *tusState) (int64, []repository.UploadedPart, error) {
+	parts, err := uc.storageRepo.ListParts(ctx, state.Object, state.UploadID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list parts: %w", err)
+	}
+	offset := sumPartSizes(parts)
+
+	info, err := uc.storageRepo.Stat(ctx, pendingObject(id))
+	switch {
+	case err == nil:
+		offset += info.Size
+	case !errors.Is(err, repository.ErrObjectNotFound):
+		return 0, nil, fmt.Errorf("failed to stat pending data: %w", err)
+	}
+
+	return offset, parts, nil
+}
+
+func (uc *TusUseCase) loadState(ctx context.Context, id string) (*tusState, error) {
+	if _, _, err := parseUploadID(id); err != nil {
+		return nil, err
+	}
+
+	obj, err := uc.storageRepo.Download(ctx, stateObject(id))
+	if errors.Is(err, repository.ErrObjectNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload state: %w", err)
+	}
+	defer obj.Close()
+
+	var state tusState
+	if err := json.NewDecoder(obj).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode upload state: %w", err)
+	}
+	return &state, nil
+}
+
+func (uc *TusUseCase) saveState(ctx context.Context, id string, state *tusState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return uc.storageRepo.Upload(ctx, bytes.NewReader(data), stateObject(id), int64(len(data)), "application/json")
+}
+
+func inputObject(job *entity.Job, input string) (string, error) {
+	switch input {
+	case "media":
+		return job.MediaPath, nil
+	case "audio":
+		return job.AudioPath, nil
+	default:
+		return "", fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "input", Code: service.CodeInvalidRequest,
+			Message: fmt.Sprintf("invalid input: %q (allowed: media, audio)", input)})
+	}
+}
+
+// parseUploadID splits an upload ID, {job}.{input}, into its parts.
+func parseUploadID(id string) (jobUUID, input string, err error) {
+	jobUUID, input, _ = strings.Cut(id, ".")
+	if uuid.Validate(jobUUID) != nil || (input != "media" && input != "audio") {
+		return "", "", ErrUploadNotFound
+	}
+	return jobUUID, input, nil
+}
+
+// stateObject and pendingObject name the objects kept for a valid upload ID
+// while it is unfinished.
+func stateObject(id string) string {
+	return tusPrefix + strings.ReplaceAll(id, ".", "/") + ".json"
+}
+
+func pendingObject(id string) string {
+	return tusPrefix + strings.ReplaceAll(id, ".", "/") + ".part"
+}
+
+func sumPartSizes(parts []repository.UploadedPart) int64 {
+	var size int64
+	for _, part := range parts {
+		size += part.Size
+	}
+	return size
+}
+
+// keyedMutex serializes work on the same key, such as concurrent PATCH
+// requests to one upload. Entries are dropped once no one holds them.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (k *keyedMutex) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
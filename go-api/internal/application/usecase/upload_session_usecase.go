@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/domain/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// multipartThreshold is the input size above which a session hands out
+	// part URLs instead of a single PUT URL.
+	multipartThreshold = 64 << 20
+	minPartSize        = 16 << 20
+	maxParts           = 10000
+
+	TransportPresigned = "presigned"
+	TransportTus       = "tus"
+)
+
+// ErrJobNotUploading is returned when committing a job that is not waiting
+// for a direct upload, e.g. one that was already committed.
+var ErrJobNotUploading = errors.New("job is not awaiting upload")
+
+// UploadSessionUseCase lets clients upload job inputs straight to storage
+// with presigned URLs and enqueue the job once they are in place.
+type UploadSessionUseCase struct {
+	jobRepo       repository.JobRepository
+	storageRepo   repository.StorageRepository
+	queueRepo     repository.QueueRepository
+	validationSvc *service.ValidationService
+	quotaUseCase  *QuotaUseCase
+	logger        *logrus.Logger
+	baseURL       string
+	expiry        time.Duration
+}
+
+func NewUploadSessionUseCase(
+	jobRepo repository.JobRepository,
+	storageRepo repository.StorageRepository,
+	queueRepo repository.QueueRepository,
+	validationSvc *service.ValidationService,
+	quotaUseCase *QuotaUseCase,
+	logger *logrus.Logger,
+	baseURL string,
+	expiry time.Duration,
+) *UploadSessionUseCase {
+	return &UploadSessionUseCase{
+		jobRepo:       jobRepo,
+		storageRepo:   storageRepo,
+		queueRepo:     queueRepo,
+		validationSvc: validationSvc,
+		quotaUseCase:  quotaUseCase,
+		logger:        logger,
+		baseURL:       baseURL,
+		expiry:        expiry,
+	}
+}
+
+// Create validates the job options, records the job as uploading and
+// returns presigned URLs for its media and audio.
+func (uc *UploadSessionUseCase) Create(ctx context.Context, req dto.UploadSessionRequest) (*dto.UploadSessionResponse, error) {
+	if req.Media.Size <= 0 || req.Audio.Size <= 0 {
+		return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "size", Code: service.CodeInvalidRequest,
+			Message: "media and audio sizes are required"})
+	}
+	switch req.Transport {
+	case "", TransportPresigned, TransportTus:
+	default:
+		return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "transport", Code: service.CodeInvalidOption,
+			Message: fmt.Sprintf("invalid transport: %s (allowed: presigned, tus)", req.Transport)})
+	}
+	if err := uc.validationSvc.ValidateSize("media", req.Media.Filename, req.Media.Size); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := uc.validationSvc.ValidateSize("audio", req.Audio.Filename, req.Audio.Size); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	uploadReq := dto.UploadRequest{
+		MediaFilename: path.Base(req.Media.Filename),
+		AudioFilename: path.Base(req.Audio.Filename),
+		Preset:        req.Preset,
+		OutputMode:    req.OutputMode,
+		Motion:        req.Motion,
+		Visualizer: entity.Visualizer{
+			Style:    req.Visualizer,
+			Color:    req.VisualizerColor,
+			Position: req.VisualizerPosition,
+		},
+		Loudnorm: entity.Loudnorm{
+			Enabled:    req.Loudnorm,
+			Integrated: req.LoudnormTarget,
+			TruePeak:   req.LoudnormTruePeak,
+		},
+	}
+	job, err := newComposeJob(uc.validationSvc, &uploadReq)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = entity.JobStatusUploading
+	job.Owner = jobOwner(ctx)
+	// Announced sizes hold the quota until the commit measures the inputs.
+	job.StorageBytes = req.Media.Size + req.Audio.Size
+
+	if err := uc.quotaUseCase.Check(ctx, job.StorageBytes); err != nil {
+		return nil, err
+	}
+
+	log := uc.logger.WithFields(logrus.Fields{
+		"job_uuid": job.UUID,
+		"media":    uploadReq.MediaFilename,
+		"audio":    uploadReq.AudioFilename,
+	})
+
+	resp := &dto.UploadSessionResponse{
+		UUID:      job.UUID,
+		ExpiresAt: time.Now().UTC().Add(uc.expiry),
+		CommitURL: fmt.Sprintf("%s/jobs/%s/commit", uc.baseURL, job.UUID),
+		Media:     dto.PresignedUpload{Object: job.MediaPath},
+		Audio:     dto.PresignedUpload{Object: job.AudioPath},
+	}
+
+	if req.Transport == TransportTus {
+		resp.TusURL = uc.baseURL + "/files"
+		if err := uc.jobRepo.Create(ctx, job); err != nil {
+			log.WithError(err).Error("Failed to create job")
+			return nil, fmt.Errorf("failed to create job: %w", err)
+		}
+		log.Info("Tus upload session created")
+		return resp, nil
+	}
+
+	resp.Media, err = uc.presignUpload(ctx, job.MediaPath, req.Media)
+	if err != nil {
+		log.WithError(err).Error("Failed to presign media upload")
+		return nil, fmt.Errorf("failed to presign media upload: %w", err)
+	}
+
+	resp.Audio, err = uc.presignUpload(ctx, job.AudioPath, req.Audio)
+	if err != nil {
+		log.WithError(err).Error("Failed to presign audio upload")
+		return nil, fmt.Errorf("failed to presign audio upload: %w", err)
+	}
+
+	if err := uc.jobRepo.Create(ctx, job); err != nil {
+		log.WithError(err).Error("Failed to create job")
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"media_parts": len(resp.Media.Parts),
+		"audio_parts": len(resp.Audio.Parts),
+	}).Info("Upload session created")
+
+	return resp, nil
+}
+
+func (uc *UploadSessionUseCase) presignUpload(ctx context.Context, objectName string, file dto.SessionFile) (dto.PresignedUpload, error) {
+	upload := dto.PresignedUpload{Object: objectName}
+
+	if file.Size <= multipartThreshold {
+		url, err := uc.storageRepo.PresignPut(ctx, objectName, uc.expiry)
+		if err != nil {
+			return upload, err
+		}
+		upload.URL = url
+		return upload, nil
+	}
+
+	uploadID, err := uc.storageRepo.CreateMultipartUpload(ctx, objectName, file.ContentType)
+	if err != nil {
+		return upload, err
+	}
+	upload.UploadID = uploadID
+	upload.PartSize = partSize(file.Size)
+
+	count := int((file.Size + upload.PartSize - 1) / upload.PartSize)
+	upload.Parts = make([]dto.PresignedPart, count)
+	for i := range upload.Parts {
+		url, err := uc.storageRepo.PresignUploadPart(ctx, objectName, uploadID, i+1, uc.expiry)
+		if err != nil {
+			return upload, err
+		}
+		upload.Parts[i] = dto.PresignedPart{Number: i + 1, URL: url}
+	}
+
+	return upload, nil
+}
+
+// partSize picks the smallest whole-MiB part size, at least minPartSize,
+// that fits size into maxParts parts.
+func partSize(size int64) int64 {
+	const mib = 1 << 20
+
+	needed := (size + maxParts - 1) / maxParts
+	if needed <= minPartSize {
+		return minPartSize
+	}
+	return (needed + mib - 1) / mib * mib
+}
+
+// Commit completes any multipart uploads, checks both inputs are in storage
+// and enqueues the job. Inputs whose content fails validation, or whose
+// measured size exceeds the storage quota, are deleted and the job is marked
+// failed.
+func (uc *UploadSessionUseCase) Commit(ctx context.Context, jobUUID string, req dto.CommitRequest) (*dto.UploadResponse, error) {
+	job, err := loadJob(ctx, uc.jobRepo, jobUUID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.Status != entity.JobStatusUploading {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotUploading, jobUUID, job.Status)
+	}
+
+	log := uc.logger.WithField("job_uuid", jobUUID)
+
+	var storageBytes int64
+	inputs := []struct {
+		name      string
+		object    string
+		multipart *dto.CommitMultipart
+	}{
+		{"media", job.MediaPath, req.Media},
+		{"audio", job.AudioPath, req.Audio},
+	}
+	for _, input := range inputs {
+		if input.multipart != nil {
+			parts := make([]repository.CompletedPart, len(input.multipart.Parts))
+			for i, part := range input.multipart.Parts {
+				parts[i] = repository.CompletedPart{Number: part.Number, ETag: part.ETag}
+			}
+			if err := uc.storageRepo.CompleteMultipartUpload(ctx, input.object, input.multipart.UploadID, parts); err != nil {
+				log.WithError(err).WithField("input", input.name).Error("Failed to complete upload")
+				return nil, fmt.Errorf("failed to complete %s upload: %w", input.name, err)
+			}
+		}
+
+		info, err := uc.storageRepo.Stat(ctx, input.object)
+		if errors.Is(err, repository.ErrObjectNotFound) {
+			return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: input.name, Code: service.CodeMissingFile,
+				Message: fmt.Sprintf("%s was not uploaded", input.name)})
+		}
+		if err != nil {
+			log.WithError(err).WithField("input", input.name).Error("Failed to stat upload")
+			return nil, fmt.Errorf("failed to stat %s upload: %w", input.name, err)
+		}
+		if info.Size == 0 {
+			return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: input.name, Code: service.CodeEmptyFile,
+				Message: fmt.Sprintf("%s is empty", input.name)})
+		}
+		storageBytes += info.Size
+
+		probe, err := uc.inspectInput(ctx, input.name, input.object, info.Size)
+		if err != nil {
+			var validationErr *service.ValidationError
+			if errors.As(err, &validationErr) {
+				uc.reject(ctx, job, validationErr, entity.FailureCodeInvalidInput)
+			}
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+		if input.name == "media" {
+			job.MediaInfo = probe
+		}
+	}
+
+	// Usage already counts the announced sizes; only growth beyond them
+	// needs room.
+	if err := uc.quotaUseCase.Check(ctx, storageBytes-job.StorageBytes); err != nil {
+		var quotaErr *QuotaError
+		if errors.As(err, &quotaErr) {
+			uc.reject(ctx, job, quotaErr, entity.FailureCodeQuotaExceeded)
+		}
+		return nil, err
+	}
+
+	update := entity.JobStatusUpdate{
+		Status:       entity.JobStatusPending,
+		MediaInfo:    job.MediaInfo,
+		StorageBytes: &storageBytes,
+	}
+	// Only the commit that moves the job out of uploading publishes it; a
+	// concurrent commit, or a cancel, may have got there first.
+	committed, err := uc.jobRepo.TransitionStatus(ctx, jobUUID, []entity.JobStatus{entity.JobStatusUploading}, update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update job status: %w", err)
+	}
+	if !committed {
+		return nil, fmt.Errorf("%w: job %s was committed or cancelled meanwhile", ErrJobNotUploading, jobUUID)
+	}
+	job.Status = entity.JobStatusPending
+
+	if err := uc.queueRepo.PublishJob(ctx, job); err != nil {
+		log.WithError(err).Error("Failed to publish job")
+		return nil, fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	log.Info("Upload committed and job published")
+
+	return &dto.UploadResponse{UUID: jobUUID, Media: job.MediaInfo}, nil
+}
+
+// inspectInput validates the content of an uploaded input in place.
+func (uc *UploadSessionUseCase) inspectInput(ctx context.Context, field, object string, size int64) (*entity.MediaInfo, error) {
+	obj, err := uc.storageRepo.Download(ctx, object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", field, err)
+	}
+	defer obj.Close()
+
+	return uc.validationSvc.ValidateContent(field, path.Base(object), objectReaderAt{obj}, size)
+}
+
+// reject fails a job whose inputs were not accepted and deletes them. A
+// job a concurrent commit already moved on is left alone.
+func (uc *UploadSessionUseCase) reject(ctx context.Context, job *entity.Job, cause error, failureCode string) {
+	log := uc.logger.WithField("job_uuid", job.UUID)
+	log.WithError(cause).Warn("Rejected uploaded input")
+
+	var storageBytes int64
+	update := entity.JobStatusUpdate{
+		Status:       entity.JobStatusFailed,
+		ErrorMessage: cause.Error(),
+		FailureCode:  failureCode,
+		StorageBytes: &storageBytes,
+	}
+	rejected, err := uc.jobRepo.TransitionStatus(ctx, job.UUID, []entity.JobStatus{entity.JobStatusUploading}, update)
+	if err != nil {
+		log.WithError(err).Error("Failed to update job status")
+		return
+	}
+	if !rejected {
+		return
+	}
+
+	for _, object := range []string{job.MediaPath, job.AudioPath} {
+		if err := uc.storageRepo.Delete(ctx, object); err != nil {
+			log.WithError(err).WithField("object", object).Warn("Failed to delete rejected input")
+		}
+	}
+}
+
+// objectReaderAt reads a stored object at arbitrary offsets; each read
+// after a seek fetches only a range from storage.
+type objectReaderAt struct {
+	io.ReadSeeker
+}
+
+func (o objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := o.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(o.ReadSeeker, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -1,9 +1,10 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"path/filepath"
 
 	"github.com/airlance/api/internal/application/dto"
@@ -19,6 +20,7 @@ type UploadUseCase struct {
 	storageRepo   repository.StorageRepository
 	queueRepo     repository.QueueRepository
 	validationSvc *service.ValidationService
+	quotaUseCase  *QuotaUseCase
 	logger        *logrus.Logger
 }
 
@@ -27,6 +29,7 @@ func NewUploadUseCase(
 	storageRepo repository.StorageRepository,
 	queueRepo repository.QueueRepository,
 	validationSvc *service.ValidationService,
+	quotaUseCase *QuotaUseCase,
 	logger *logrus.Logger,
 ) *UploadUseCase {
 	return &UploadUseCase{
@@ -34,34 +37,48 @@ func NewUploadUseCase(
 		storageRepo:   storageRepo,
 		queueRepo:     queueRepo,
 		validationSvc: validationSvc,
+		quotaUseCase:  quotaUseCase,
 		logger:        logger,
 	}
 }
 
-func (uc *UploadUseCase) Execute(ctx context.Context, req dto.UploadRequest, mediaReader, audioReader io.Reader) (*dto.UploadResponse, error) {
-	if err := uc.validationSvc.ValidateMediaFile(req.MediaFilename); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+func (uc *UploadUseCase) Execute(ctx context.Context, req dto.UploadRequest, mediaReader, audioReader dto.FileReader) (*dto.UploadResponse, error) {
+	job, err := newComposeJob(uc.validationSvc, &req)
+	if err != nil {
+		return nil, err
 	}
-
-	if err := uc.validationSvc.ValidateAudioFile(req.AudioFilename); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	job.Owner = jobOwner(ctx)
+	job.StorageBytes = req.MediaSize + req.AudioSize
+	if req.Subtitles != nil {
+		job.StorageBytes += req.Subtitles.Size
 	}
+	jobID := job.UUID
 
-	jobID := uuid.New().String()
-
-	job := &entity.Job{
-		UUID:      jobID,
-		MediaPath: filepath.Join(jobID, req.MediaFilename),
-		AudioPath: filepath.Join(jobID, req.AudioFilename),
-		Status:    entity.JobStatusPending,
+	if err := uc.quotaUseCase.Check(ctx, job.StorageBytes); err != nil {
+		return nil, err
 	}
 
 	log := uc.logger.WithFields(logrus.Fields{
-		"job_uuid": jobID,
-		"media":    req.MediaFilename,
-		"audio":    req.AudioFilename,
+		"job_uuid":   jobID,
+		"media":      req.MediaFilename,
+		"audio":      req.AudioFilename,
+		"preset":     req.Preset,
+		"output":     req.OutputMode,
+		"motion":     req.Motion,
+		"visualizer": req.Visualizer.Style,
+		"loudnorm":   req.Loudnorm.Enabled,
 	})
 
+	job.MediaInfo, err = uc.validationSvc.ValidateContent("media", req.MediaFilename, mediaReader, req.MediaSize)
+	if err != nil {
+		log.WithError(err).Warn("Rejected media")
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := uc.validationSvc.ValidateContent("audio", req.AudioFilename, audioReader, req.AudioSize); err != nil {
+		log.WithError(err).Warn("Rejected audio")
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	if err := uc.storageRepo.Upload(ctx, mediaReader, job.MediaPath, req.MediaSize, req.MediaContentType); err != nil {
 		log.WithError(err).Error("Failed to upload media")
 		return nil, fmt.Errorf("failed to upload media: %w", err)
@@ -72,6 +89,13 @@ func (uc *UploadUseCase) Execute(ctx context.Context, req dto.UploadRequest, med
 		return nil, fmt.Errorf("failed to upload audio: %w", err)
 	}
 
+	if req.Subtitles != nil {
+		if err := uc.storageRepo.Upload(ctx, req.Subtitles.Reader, job.Subtitles.Path, req.Subtitles.Size, req.Subtitles.ContentType); err != nil {
+			log.WithError(err).Error("Failed to upload subtitles")
+			return nil, fmt.Errorf("failed to upload subtitles: %w", err)
+		}
+	}
+
 	if err := uc.jobRepo.Create(ctx, job); err != nil {
 		log.WithError(err).Error("Failed to create job")
 		return nil, fmt.Errorf("failed to create job: %w", err)
@@ -84,5 +108,202 @@ func (uc *UploadUseCase) Execute(ctx context.Context, req dto.UploadRequest, med
 
 	log.Info("Job created and published")
 
-	return &dto.UploadResponse{UUID: jobID}, nil
+	return &dto.UploadResponse{UUID: jobID, Media: job.MediaInfo}, nil
+}
+
+// ExecuteTimeline stores a timeline manifest and every asset it references
+// under the job prefix, then enqueues the job.
+func (uc *UploadUseCase) ExecuteTimeline(ctx context.Context, req dto.TimelineUploadRequest) (*dto.UploadResponse, error) {
+	var timeline entity.Timeline
+	if err := json.Unmarshal(req.Manifest, &timeline); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "manifest", Code: service.CodeInvalidTimeline,
+			Message: fmt.Sprintf("invalid timeline manifest: %v", err)})
+	}
+
+	assetNames := make([]string, len(req.Assets))
+	for i, asset := range req.Assets {
+		assetNames[i] = asset.Filename
+	}
+	if err := uc.validationSvc.ValidateTimeline(&timeline, assetNames); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.Preset == "" {
+		req.Preset = entity.DefaultPreset
+	}
+	if err := uc.validationSvc.ValidatePreset(req.Preset); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if preset, _ := entity.LookupPreset(req.Preset); preset.AudioOnly {
+		return nil, fmt.Errorf("validation failed: %w", &service.ValidationError{Field: "preset", Code: service.CodeInvalidOption,
+			Message: "timeline jobs need a video preset"})
+	}
+
+	if req.OutputMode == "" {
+		req.OutputMode = entity.OutputModeFile
+	}
+	if err := uc.validationSvc.ValidateOutputMode(req.OutputMode, req.Preset); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := uc.validationSvc.ValidateLoudnorm(req.Loudnorm); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	var storageBytes int64
+	for _, asset := range req.Assets {
+		storageBytes += asset.Size
+	}
+	if err := uc.quotaUseCase.Check(ctx, storageBytes); err != nil {
+		return nil, err
+	}
+
+	jobID := uuid.New().String()
+
+	job := &entity.Job{
+		UUID:         jobID,
+		Type:         entity.JobTypeTimeline,
+		Owner:        jobOwner(ctx),
+		Preset:       req.Preset,
+		OutputMode:   req.OutputMode,
+		Motion:       entity.MotionNone,
+		Visualizer:   entity.Visualizer{Style: entity.VisualizerNone},
+		Loudnorm:     req.Loudnorm,
+		Status:       entity.JobStatusPending,
+		StorageBytes: storageBytes,
+	}
+
+	log := uc.logger.WithFields(logrus.Fields{
+		"job_uuid": jobID,
+		"items":    len(timeline.Items),
+		"assets":   len(req.Assets),
+		"preset":   req.Preset,
+		"output":   req.OutputMode,
+		"loudnorm": req.Loudnorm.Enabled,
+	})
+
+	job.AssetInfo = make(map[string]*entity.MediaInfo, len(req.Assets))
+	for _, asset := range req.Assets {
+		info, err := uc.validationSvc.ValidateContent("assets", asset.Filename, asset.Reader, asset.Size)
+		if err != nil {
+			log.WithError(err).WithField("asset", asset.Filename).Warn("Rejected asset")
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+		job.AssetInfo[asset.Filename] = info
+	}
+
+	for _, asset := range req.Assets {
+		if err := uc.storageRepo.Upload(ctx, asset.Reader, job.AssetPath(asset.Filename), asset.Size, asset.ContentType); err != nil {
+			log.WithError(err).WithField("asset", asset.Filename).Error("Failed to upload asset")
+			return nil, fmt.Errorf("failed to upload asset %s: %w", asset.Filename, err)
+		}
+	}
+
+	// Store the decoded manifest so the worker never sees unknown fields.
+	manifest, err := json.Marshal(timeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode timeline: %w", err)
+	}
+	job.StorageBytes += int64(len(manifest))
+	if err := uc.storageRepo.Upload(ctx, bytes.NewReader(manifest), job.TimelinePath(), int64(len(manifest)), "application/json"); err != nil {
+		log.WithError(err).Error("Failed to upload timeline")
+		return nil, fmt.Errorf("failed to upload timeline: %w", err)
+	}
+
+	if err := uc.jobRepo.Create(ctx, job); err != nil {
+		log.WithError(err).Error("Failed to create job")
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	if err := uc.queueRepo.PublishJob(ctx, job); err != nil {
+		log.WithError(err).Error("Failed to publish job")
+		return nil, fmt.Errorf("failed to publish job: %w", err)
+	}
+
+	log.Info("Timeline job created and published")
+
+	return &dto.UploadResponse{UUID: jobID, Assets: job.AssetInfo}, nil
+}
+
+// newComposeJob validates an upload request, fills in defaults and builds the
+// job it describes. Input paths are derived from the new job's UUID.
+func newComposeJob(validationSvc *service.ValidationService, req *dto.UploadRequest) (*entity.Job, error) {
+	if err := validationSvc.ValidateMediaFile(req.MediaFilename); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := validationSvc.ValidateAudioFile(req.AudioFilename); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.Preset == "" {
+		req.Preset = entity.DefaultPreset
+	}
+	if err := validationSvc.ValidatePreset(req.Preset); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.OutputMode == "" {
+		req.OutputMode = entity.OutputModeFile
+	}
+	if err := validationSvc.ValidateOutputMode(req.OutputMode, req.Preset); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.Motion == "" {
+		req.Motion = entity.MotionNone
+	}
+	if err := validationSvc.ValidateMotion(req.Motion, req.MediaFilename); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	var subtitles entity.Subtitles
+	if req.Subtitles != nil {
+		if err := validationSvc.ValidateSubtitleFile(req.Subtitles.Filename); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+
+		subtitles = entity.Subtitles{
+			Mode:     req.SubtitlesMode,
+			FontSize: req.SubtitlesFontSize,
+			Color:    req.SubtitlesColor,
+		}
+		if subtitles.Mode == "" {
+			subtitles.Mode = entity.SubtitlesBurn
+		}
+		if err := validationSvc.ValidateSubtitles(subtitles, req.Preset, req.OutputMode); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if req.Visualizer.Style == "" {
+		req.Visualizer.Style = entity.VisualizerNone
+	}
+	if err := validationSvc.ValidateVisualizer(req.Visualizer, req.MediaFilename, req.Preset); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := validationSvc.ValidateLoudnorm(req.Loudnorm); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	jobID := uuid.New().String()
+
+	job := &entity.Job{
+		UUID:       jobID,
+		Type:       entity.JobTypeCompose,
+		MediaPath:  filepath.Join(jobID, req.MediaFilename),
+		AudioPath:  filepath.Join(jobID, req.AudioFilename),
+		Preset:     req.Preset,
+		OutputMode: req.OutputMode,
+		Motion:     req.Motion,
+		Visualizer: req.Visualizer,
+		Loudnorm:   req.Loudnorm,
+		Status:     entity.JobStatusPending,
+	}
+	if req.Subtitles != nil {
+		subtitles.Path = filepath.Join(jobID, req.Subtitles.Filename)
+		job.Subtitles = subtitles
+	}
+
+	return job, nil
 }
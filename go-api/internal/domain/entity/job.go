@@ -3,19 +3,145 @@ package entity
 import "time"
 
 type Job struct {
-	UUID      string
-	MediaPath string
-	AudioPath string
-	Status    JobStatus
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	UUID         string
+	Type         JobType
+	Owner        string // subject of the access token that created the job
+	MediaPath    string
+	AudioPath    string
+	Preset       string
+	OutputMode   string
+	Motion       string
+	Visualizer   Visualizer
+	Subtitles    Subtitles
+	Loudnorm     Loudnorm
+	MediaInfo    *MediaInfo            // media input as probed at upload
+	AssetInfo    map[string]*MediaInfo // timeline asset probes by name
+	StorageBytes int64                 // size of the job's stored inputs and outputs
+	Status       JobStatus
+	ErrorMessage string
+	FailureCode  string
+	WorkerID     string
+	Progress     float64
+	Result       *JobResult
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	StartedAt    *time.Time
+	CompletedAt  *time.Time
+	DeletedAt    *time.Time // set once the job's objects were removed
+	// InputsDeletedAt is set once retention removed the inputs of a ready job.
+	InputsDeletedAt *time.Time
 }
 
+// JobType distinguishes a single media file over an audio track from a
+// timeline manifest with several assets.
+type JobType string
+
+const (
+	JobTypeCompose  JobType = "compose"
+	JobTypeTimeline JobType = "timeline"
+)
+
 type JobStatus string
 
+// CancelMarkerDir is where cancel markers are kept in the bucket.
+const CancelMarkerDir = ".cancelled/"
+
 const (
+	// JobStatusUploading is a job whose inputs are being uploaded directly
+	// to storage; it is enqueued once the upload is committed.
+	JobStatusUploading  JobStatus = "uploading"
 	JobStatusPending    JobStatus = "pending"
 	JobStatusProcessing JobStatus = "processing"
 	JobStatusReady      JobStatus = "ready"
 	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
+)
+
+// OutputPreset returns the job's preset, falling back to the default for jobs
+// created before presets existed.
+func (j *Job) OutputPreset() Preset {
+	if preset, ok := LookupPreset(j.Preset); ok {
+		return preset
+	}
+	preset, _ := LookupPreset(DefaultPreset)
+	return preset
+}
+
+// OutputPath is the storage object name of the job's rendered output.
+func (j *Job) OutputPath() string {
+	return j.UUID + "/" + j.OutputPreset().OutputName()
+}
+
+// TimelinePath is the storage object name of a timeline job's manifest.
+func (j *Job) TimelinePath() string {
+	return j.UUID + "/" + TimelineManifest
+}
+
+// AssetPath is the storage object name of a timeline asset.
+func (j *Job) AssetPath(name string) string {
+	return j.UUID + "/" + TimelineAssetDir + "/" + name
+}
+
+// ArtifactPath is the storage object name of a file uploaded next to the output.
+func (j *Job) ArtifactPath(file string) string {
+	return j.UUID + "/" + file
+}
+
+// CancelMarkerPath is the storage object name recording that the job was
+// cancelled. It lives outside the job's prefix so it is not listed among its
+// artifacts; workers look for it before they start a job.
+func (j *Job) CancelMarkerPath() string {
+	return CancelMarkerDir + j.UUID
+}
+
+// ProcessingTime is how long workers spent on the job after since, up to
+// its completion or now if it is still processing.
+func (j *Job) ProcessingTime(since, now time.Time) time.Duration {
+	if j.StartedAt == nil {
+		return 0
+	}
+	start, end := *j.StartedAt, now
+	if j.CompletedAt != nil {
+		end = *j.CompletedAt
+	}
+	if start.Before(since) {
+		start = since
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// IsTerminal reports whether no further status transitions are expected.
+func (s JobStatus) IsTerminal() bool {
+	return s == JobStatusReady || s == JobStatusFailed || s == JobStatusCancelled
+}
+
+// Failure codes reported by workers alongside a failed status.
+const (
+	FailureCodeError   = "error"
+	FailureCodeTimeout = "timeout"
+	// FailureCodeInvalidInput marks direct uploads rejected on commit.
+	FailureCodeInvalidInput = "invalid_input"
+	// FailureCodeQuotaExceeded marks direct uploads whose measured size
+	// no longer fits the owner's storage quota on commit.
+	FailureCodeQuotaExceeded = "quota_exceeded"
 )
+
+// JobStatusUpdate describes a status transition reported for a job.
+type JobStatusUpdate struct {
+	Status       JobStatus
+	ErrorMessage string
+	FailureCode  string
+	WorkerID     string
+	Progress     float64
+	// Result is only set on ready events; nil keeps the stored result.
+	Result *JobResult
+	// MediaInfo is set when a direct upload is committed; nil keeps the
+	// stored probe.
+	MediaInfo *MediaInfo
+	// StorageBytes is set when the job's stored objects were measured; nil
+	// keeps the stored size.
+	StorageBytes *int64
+}
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// JobSort is the timestamp jobs are listed by.
+type JobSort string
+
+const (
+	JobSortCreatedAt JobSort = "created_at"
+	JobSortUpdatedAt JobSort = "updated_at"
+)
+
+// JobFilter selects a page of jobs. Zero fields match every job.
+type JobFilter struct {
+	Owner         string
+	Statuses      []JobStatus
+	MediaType     *MediaType // type of a compose job's probed media input
+	CreatedAfter  time.Time  // inclusive
+	CreatedBefore time.Time  // exclusive
+	Sort          JobSort
+	Descending    bool
+	// After continues a listing past the last job of the previous page.
+	After *JobCursor
+	Limit int
+
+	// CompletedBefore only matches jobs that finished before it.
+	CompletedBefore time.Time
+	// InputsKept only matches jobs whose inputs were not deleted.
+	InputsKept bool
+}
+
+// JobCursor is the position of a job in a listing: its sort timestamp, with
+// the UUID breaking ties.
+type JobCursor struct {
+	Time time.Time
+	UUID string
+}
+
+// SortTime returns the timestamp the job is listed by.
+func (j *Job) SortTime(sort JobSort) time.Time {
+	if sort == JobSortUpdatedAt {
+		return j.UpdatedAt
+	}
+	return j.CreatedAt
+}
+
+// Cursor returns the job's position in a listing sorted by sort.
+func (j *Job) Cursor(sort JobSort) JobCursor {
+	return JobCursor{Time: j.SortTime(sort), UUID: j.UUID}
+}
@@ -0,0 +1,24 @@
+package entity
+
+// Loudnorm requests EBU R128 loudness normalization of a job's audio track.
+// Zero targets leave the choice to the worker's configured defaults.
+type Loudnorm struct {
+	Enabled    bool
+	Integrated float64
+	TruePeak   float64
+}
+
+// LoudnessStats are the loudnorm measurements of the audio track before and
+// after normalization, in LUFS, dBTP and LU.
+type LoudnessStats struct {
+	InputIntegrated   float64 `json:"input_i"`
+	InputTruePeak     float64 `json:"input_tp"`
+	InputLRA          float64 `json:"input_lra"`
+	InputThreshold    float64 `json:"input_thresh"`
+	OutputIntegrated  float64 `json:"output_i"`
+	OutputTruePeak    float64 `json:"output_tp"`
+	OutputLRA         float64 `json:"output_lra"`
+	TargetIntegrated  float64 `json:"target_i"`
+	TargetTruePeak    float64 `json:"target_tp"`
+	NormalizationType string  `json:"normalization_type"`
+}
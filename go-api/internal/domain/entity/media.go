@@ -1,6 +1,9 @@
 package entity
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
 
 type Media struct {
 	Filename    string
@@ -16,3 +19,64 @@ const (
 	MediaTypeVideo
 	MediaTypeAudio
 )
+
+func (t MediaType) String() string {
+	switch t {
+	case MediaTypeImage:
+		return "image"
+	case MediaTypeVideo:
+		return "video"
+	default:
+		return "audio"
+	}
+}
+
+func (t MediaType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *MediaType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "image":
+		*t = MediaTypeImage
+	case "video":
+		*t = MediaTypeVideo
+	case "audio":
+		*t = MediaTypeAudio
+	default:
+		return fmt.Errorf("unknown media type: %s", text)
+	}
+	return nil
+}
+
+// Container formats recognized from file content.
+const (
+	FormatJPEG     = "jpeg"
+	FormatPNG      = "png"
+	FormatWebP     = "webp"
+	FormatMP4      = "mp4"
+	FormatMOV      = "mov"
+	FormatM4A      = "m4a"
+	FormatMatroska = "matroska"
+	FormatWebM     = "webm"
+	FormatAVI      = "avi"
+	FormatMP3      = "mp3"
+	FormatWAV      = "wav"
+	FormatAAC      = "aac"
+)
+
+// MediaInfo describes an input as detected from its content rather than its
+// name. Width and Height are zero for audio, Duration (in seconds) for
+// images and when the container does not record it. Codecs are short
+// lowercase names such as "h264" or "aac", empty for images and when the
+// container does not say.
+type MediaInfo struct {
+	Format     string    `json:"format"`
+	Type       MediaType `json:"type"`
+	Width      int       `json:"width,omitempty"`
+	Height     int       `json:"height,omitempty"`
+	Duration   float64   `json:"duration,omitempty"`
+	VideoCodec string    `json:"video_codec,omitempty"`
+	AudioCodec string    `json:"audio_codec,omitempty"`
+	HasAudio   bool      `json:"has_audio"`
+}
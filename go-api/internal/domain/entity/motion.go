@@ -0,0 +1,16 @@
+package entity
+
+// Motion effects the worker can apply to still-image jobs.
+const (
+	MotionNone     = "none"
+	MotionZoomIn   = "zoom-in"
+	MotionZoomOut  = "zoom-out"
+	MotionPanLeft  = "pan-left"
+	MotionPanRight = "pan-right"
+	MotionRandom   = "random"
+)
+
+// MotionNames lists the supported motion effects.
+func MotionNames() []string {
+	return []string{MotionNone, MotionZoomIn, MotionZoomOut, MotionPanLeft, MotionPanRight, MotionRandom}
+}
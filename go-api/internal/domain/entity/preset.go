@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"path"
+	"strings"
+)
+
+const DefaultPreset = "h264-mp4"
+
+// Output modes: a single progressive file, or that file plus an adaptive
+// stream packaged under {uuid}/hls/.
+const (
+	OutputModeFile    = "file"
+	OutputModeHLS     = "hls"
+	OutputModeHLSDASH = "hls+dash"
+
+	StreamDir         = "hls"
+	HLSMasterPlaylist = "master.m3u8"
+	DASHManifest      = "manifest.mpd"
+)
+
+// Preset names an output format rendered by the worker. The encoder settings
+// live in go-av; the API only needs to know where the output ends up.
+type Preset struct {
+	Name        string
+	Extension   string
+	ContentType string
+	// Streamable presets can also be packaged as HLS/DASH.
+	Streamable bool
+	AudioOnly  bool
+}
+
+var presets = map[string]Preset{
+	"h264-mp4":       {Name: "h264-mp4", Extension: "mp4", ContentType: "video/mp4", Streamable: true},
+	"h265-mp4":       {Name: "h265-mp4", Extension: "mp4", ContentType: "video/mp4", Streamable: true},
+	"vp9-webm":       {Name: "vp9-webm", Extension: "webm", ContentType: "video/webm"},
+	"av1-mkv":        {Name: "av1-mkv", Extension: "mkv", ContentType: "video/x-matroska"},
+	"audio-only-m4a": {Name: "audio-only-m4a", Extension: "m4a", ContentType: "audio/mp4", AudioOnly: true},
+}
+
+// LookupPreset returns the preset with the given name.
+func LookupPreset(name string) (Preset, bool) {
+	preset, ok := presets[name]
+	return preset, ok
+}
+
+// PresetNames lists the supported preset names.
+func PresetNames() []string {
+	return []string{"h264-mp4", "h265-mp4", "vp9-webm", "av1-mkv", "audio-only-m4a"}
+}
+
+// IsStreamingMode reports whether an output mode produces a segmented stream.
+func IsStreamingMode(mode string) bool {
+	return mode == OutputModeHLS || mode == OutputModeHLSDASH
+}
+
+// StreamContentType returns the MIME type for a file of a packaged stream.
+func StreamContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// OutputName is the object name of the rendered file inside the job prefix.
+func (p Preset) OutputName() string {
+	return "output." + p.Extension
+}
@@ -0,0 +1,28 @@
+package entity
+
+// JobResult holds what a worker measured and produced while rendering a job.
+type JobResult struct {
+	Loudness  *LoudnessStats `json:"loudness,omitempty"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+}
+
+// Artifact is an extra file a worker uploaded next to the job output, such
+// as a poster frame or a thumbnail sprite sheet.
+type Artifact struct {
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	ContentType string `json:"content_type"`
+}
+
+// Artifact looks up an artifact by its file name.
+func (r *JobResult) Artifact(file string) (Artifact, bool) {
+	if r == nil {
+		return Artifact{}, false
+	}
+	for _, a := range r.Artifacts {
+		if a.File == file {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
@@ -0,0 +1,21 @@
+package entity
+
+// Subtitle modes: drawn into the picture, or muxed as a selectable track.
+const (
+	SubtitlesBurn = "burn"
+	SubtitlesSoft = "soft"
+)
+
+// Subtitles is an optional caption file uploaded with a job. FontSize and
+// Color only style burned-in captions; zero values keep the file's styling.
+type Subtitles struct {
+	Path     string
+	Mode     string
+	FontSize int
+	Color    string
+}
+
+// Enabled reports whether the job has a subtitle file.
+func (s Subtitles) Enabled() bool {
+	return s.Path != ""
+}
@@ -0,0 +1,47 @@
+package entity
+
+// Timeline jobs store their manifest at {uuid}/timeline.json and every
+// referenced asset under {uuid}/assets/.
+const (
+	TimelineManifest = "timeline.json"
+	TimelineAssetDir = "assets"
+
+	TransitionCut       = "cut"
+	TransitionCrossfade = "crossfade"
+)
+
+// Timeline is the manifest of a timeline job: images and clips shown in
+// sequence over a music and a voiceover track.
+type Timeline struct {
+	Width     int            `json:"width,omitempty"`
+	Height    int            `json:"height,omitempty"`
+	Items     []TimelineItem `json:"items"`
+	Music     *TimelineAudio `json:"music,omitempty"`
+	Voiceover *TimelineAudio `json:"voiceover,omitempty"`
+	Ducking   bool           `json:"ducking,omitempty"`
+}
+
+type TimelineItem struct {
+	Asset              string  `json:"asset"`
+	Duration           float64 `json:"duration,omitempty"`
+	Start              float64 `json:"start,omitempty"`
+	Transition         string  `json:"transition,omitempty"`
+	TransitionDuration float64 `json:"transition_duration,omitempty"`
+}
+
+type TimelineAudio struct {
+	Asset  string  `json:"asset"`
+	Volume float64 `json:"volume,omitempty"`
+	Start  float64 `json:"start,omitempty"`
+}
+
+// AudioAssets returns the asset names used by the music and voiceover tracks.
+func (t *Timeline) AudioAssets() []string {
+	var assets []string
+	for _, track := range []*TimelineAudio{t.Music, t.Voiceover} {
+		if track != nil {
+			assets = append(assets, track.Asset)
+		}
+	}
+	return assets
+}
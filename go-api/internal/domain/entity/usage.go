@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Usage is what a user's jobs consume, as counted against their quotas.
+type Usage struct {
+	// StorageBytes is the size of the inputs and outputs the user's jobs
+	// keep in storage.
+	StorageBytes int64
+	// ProcessingTime is the worker time spent on the user's jobs since the
+	// start of the quota period.
+	ProcessingTime time.Duration
+}
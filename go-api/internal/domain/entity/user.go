@@ -0,0 +1,32 @@
+package entity
+
+import "context"
+
+// User is the caller of an authenticated request, as named by the subject
+// of its access token.
+type User struct {
+	ID    string
+	Email string
+	Role  string
+	// Admin users (service roles) may access every job.
+	Admin bool
+}
+
+// CanAccess reports whether the user may see and download the job.
+func (u *User) CanAccess(job *Job) bool {
+	return u.Admin || job.Owner == u.ID
+}
+
+type userKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the authenticated user.
+func ContextWithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user of a request. It reports
+// false when authentication is disabled.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userKey{}).(*User)
+	return user, ok
+}
@@ -0,0 +1,26 @@
+package entity
+
+// Visualizer styles and positions for the audio overlay on still images.
+const (
+	VisualizerNone     = "none"
+	VisualizerWaveform = "waveform"
+	VisualizerSpectrum = "spectrum"
+	VisualizerCircle   = "circle"
+
+	VisualizerTop    = "top"
+	VisualizerCenter = "center"
+	VisualizerBottom = "bottom"
+)
+
+// Visualizer describes the audio visualizer drawn over an image job. Empty
+// color and position leave the choice to the worker.
+type Visualizer struct {
+	Style    string
+	Color    string
+	Position string
+}
+
+// Enabled reports whether a visualizer was requested.
+func (v Visualizer) Enabled() bool {
+	return v.Style != "" && v.Style != VisualizerNone
+}
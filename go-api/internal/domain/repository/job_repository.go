@@ -2,12 +2,32 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/airlance/api/internal/domain/entity"
 )
 
+var ErrJobNotFound = errors.New("job not found")
+
 type JobRepository interface {
 	Create(ctx context.Context, job *entity.Job) error
 	GetByUUID(ctx context.Context, uuid string) (*entity.Job, error)
-	UpdateStatus(ctx context.Context, uuid string, status entity.JobStatus) error
+	// List returns up to filter.Limit jobs matching the filter, in its sort
+	// order. Deleted jobs are never listed.
+	List(ctx context.Context, filter entity.JobFilter) ([]*entity.Job, error)
+	UpdateStatus(ctx context.Context, uuid string, update entity.JobStatusUpdate) error
+	// TransitionStatus applies the update only if the job is not deleted and
+	// is in one of the from statuses, in a single step, and reports whether
+	// it did. Unknown jobs return ErrJobNotFound.
+	TransitionStatus(ctx context.Context, uuid string, from []entity.JobStatus, update entity.JobStatusUpdate) (bool, error)
+	// MarkDeleted records that the job's objects were removed. The record is
+	// kept, without storage, so processing time still counts toward quotas.
+	MarkDeleted(ctx context.Context, uuid string) error
+	// MarkInputsDeleted records that a finished job's inputs were removed
+	// and how much of it is left in storage.
+	MarkInputsDeleted(ctx context.Context, uuid string, storageBytes int64) error
+	// Usage sums the storage of the owner's jobs and the processing time
+	// they spent since the given time.
+	Usage(ctx context.Context, owner string, since time.Time) (*entity.Usage, error)
 }
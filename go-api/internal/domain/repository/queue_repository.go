@@ -8,5 +8,8 @@ import (
 
 type QueueRepository interface {
 	PublishJob(ctx context.Context, job *entity.Job) error
+	// PublishCancel asks the workers to stop the job, whether it is running
+	// or still queued.
+	PublishCancel(ctx context.Context, jobUUID string) error
 	Close() error
 }
@@ -0,0 +1,10 @@
+package repository
+
+import "time"
+
+// RateLimiter admits events per key, e.g. uploads per user or per client IP.
+type RateLimiter interface {
+	// Allow records an event for key. When the key is over its limit it
+	// reports false and how long to wait before the next event is admitted.
+	Allow(key string) (retryAfter time.Duration, ok bool)
+}
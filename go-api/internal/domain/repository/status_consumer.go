@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+type StatusHandler func(ctx context.Context, uuid string, update entity.JobStatusUpdate) error
+
+type StatusConsumer interface {
+	Consume(ctx context.Context, handler StatusHandler) error
+	Close() error
+}
@@ -2,12 +2,77 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object. Checksum is prefixed with its
+// algorithm, e.g. "md5:<hex>" or "sha256:<base64>".
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	ContentType  string
+	ETag         string
+	Checksum     string
+	LastModified time.Time
+}
+
+// Object is an opened stored object. Reads after a Seek fetch only the
+// requested byte range from storage, so serving a range does not download
+// the whole object.
+type Object struct {
+	io.ReadSeekCloser
+	Info ObjectInfo
+}
+
+// CompletedPart is an uploaded part of a multipart upload, as reported by
+// the client that uploaded it.
+type CompletedPart struct {
+	Number int
+	ETag   string
+}
+
+// UploadedPart is a part stored for an unfinished multipart upload.
+type UploadedPart struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// LifecycleRule lets storage expire the objects under Prefix by itself once
+// they are Days old, and abort multipart uploads under it that were started
+// AbortUploadsDays ago. Zero days leave either alone.
+type LifecycleRule struct {
+	ID               string
+	Prefix           string
+	Days             int
+	AbortUploadsDays int
+}
+
 type StorageRepository interface {
 	Upload(ctx context.Context, reader io.Reader, objectName string, size int64, contentType string) error
-	Download(ctx context.Context, objectName string) (io.ReadCloser, int64, string, error)
+	Download(ctx context.Context, objectName string) (*Object, error)
 	Exists(ctx context.Context, objectName string) (bool, error)
 	Delete(ctx context.Context, objectName string) error
+	// List returns every object whose name starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Stat(ctx context.Context, objectName string) (ObjectInfo, error)
+
+	// Presigned URLs let clients transfer objects without going through the API.
+	PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	PresignGet(ctx context.Context, objectName, filename string, expiry time.Duration) (string, error)
+	PresignUploadPart(ctx context.Context, objectName, uploadID string, partNumber int, expiry time.Duration) (string, error)
+
+	CreateMultipartUpload(ctx context.Context, objectName, contentType string) (string, error)
+	UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64) (string, error)
+	ListParts(ctx context.Context, objectName, uploadID string) ([]UploadedPart, error)
+	CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error
+
+	// SetLifecycle installs lifecycle rules on the bucket, replacing rules
+	// with the same IDs and keeping any others.
+	SetLifecycle(ctx context.Context, rules []LifecycleRule) error
 }
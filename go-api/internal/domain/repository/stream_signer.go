@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidStreamToken = errors.New("invalid or expired stream token")
+
+// StreamSigner issues and checks the tokens in stream URLs, which let players
+// fetch a job's playlists and segments without an access token.
+type StreamSigner interface {
+	Sign(jobUUID string, expires time.Time) string
+	Verify(jobUUID, token string, now time.Time) error
+}
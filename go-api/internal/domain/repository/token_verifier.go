@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+// TokenVerifier checks a bearer access token and returns the user it was
+// issued to.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*entity.User, error)
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+var (
+	// ErrUnrecognizedFormat is returned for content that matches no
+	// supported image, video or audio format.
+	ErrUnrecognizedFormat = errors.New("unrecognized file format")
+	// ErrCorruptMedia is returned when a recognized format has broken or
+	// truncated headers.
+	ErrCorruptMedia = errors.New("corrupt media file")
+)
+
+var (
+	pngSignature  = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+	ebmlSignature = []byte{0x1A, 0x45, 0xDF, 0xA3}
+)
+
+// InspectMedia identifies the format of r from its magic bytes and reads
+// the dimensions and duration its container headers record. Only headers
+// are read, so r may be backed by ranged reads from storage.
+func InspectMedia(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return inspectJPEG(r, size)
+	case bytes.HasPrefix(head, pngSignature):
+		return inspectPNG(r)
+	case len(head) == 12 && string(head[:4]) == "RIFF":
+		switch string(head[8:12]) {
+		case "WEBP":
+			return inspectWebP(r)
+		case "AVI ":
+			return inspectAVI(r, size)
+		case "WAVE":
+			return inspectWAV(r, size)
+		}
+	case len(head) >= 8 && isTopLevelBox(string(head[4:8])):
+		return inspectBMFF(r, size)
+	case bytes.HasPrefix(head, ebmlSignature):
+		return inspectMatroska(r, size)
+	case bytes.HasPrefix(head, []byte("ID3")), len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return inspectMPEGAudio(r, size)
+	}
+
+	return nil, ErrUnrecognizedFormat
+}
+
+// corrupt reports a broken header in a recognized format.
+func corrupt(format, reason string) error {
+	return fmt.Errorf("%w: %s %s", ErrCorruptMedia, format, reason)
+}
+
+// readProbeSize is the read size past which readAt first checks that the
+// data exists, so a forged size cannot make it allocate more than the file.
+const readProbeSize = 64 << 10
+
+// readAt reads exactly n bytes at off; running out of data means the
+// headers are truncated.
+func readAt(r io.ReaderAt, off int64, n int, format string) ([]byte, error) {
+	if off < 0 || n < 0 || int64(n) > math.MaxInt64-off {
+		return nil, corrupt(format, "has a broken size")
+	}
+	if n > readProbeSize {
+		if _, err := readAt(r, off+int64(n)-1, 1, format); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, n)
+	if got, err := r.ReadAt(buf, off); got < n {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, corrupt(format, "is truncated")
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func inspectJPEG(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	off := int64(2)
+	for off+4 <= size {
+		b, err := readAt(r, off, 4, "jpeg")
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != 0xFF {
+			return nil, corrupt("jpeg", "has a broken marker")
+		}
+
+		marker := b[1]
+		switch {
+		case marker == 0xFF: // fill byte
+			off++
+			continue
+		case marker == 0x01, marker >= 0xD0 && marker <= 0xD8: // no payload
+			off += 2
+			continue
+		case marker == 0xD9, marker == 0xDA:
+			return nil, corrupt("jpeg", "has no frame header")
+		}
+
+		length := int64(binary.BigEndian.Uint16(b[2:]))
+		if length < 2 {
+			return nil, corrupt("jpeg", "has a broken segment")
+		}
+
+		// SOF0-SOF15, except DHT, JPG and DAC which share the range.
+		if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+			f, err := readAt(r, off+4, 5, "jpeg")
+			if err != nil {
+				return nil, err
+			}
+			info := &entity.MediaInfo{
+				Format: entity.FormatJPEG,
+				Type:   entity.MediaTypeImage,
+				Height: int(binary.BigEndian.Uint16(f[1:3])),
+				Width:  int(binary.BigEndian.Uint16(f[3:5])),
+			}
+			if info.Width == 0 || info.Height == 0 {
+				return nil, corrupt("jpeg", "has no dimensions")
+			}
+			return info, nil
+		}
+
+		off += 2 + length
+	}
+
+	return nil, corrupt("jpeg", "has no frame header")
+}
+
+func inspectPNG(r io.ReaderAt) (*entity.MediaInfo, error) {
+	b, err := readAt(r, 0, 24, "png")
+	if err != nil {
+		return nil, err
+	}
+	if string(b[12:16]) != "IHDR" {
+		return nil, corrupt("png", "does not start with IHDR")
+	}
+
+	info := &entity.MediaInfo{
+		Format: entity.FormatPNG,
+		Type:   entity.MediaTypeImage,
+		Width:  int(binary.BigEndian.Uint32(b[16:20])),
+		Height: int(binary.BigEndian.Uint32(b[20:24])),
+	}
+	if info.Width <= 0 || info.Height <= 0 {
+		return nil, corrupt("png", "has no dimensions")
+	}
+	return info, nil
+}
+
+func inspectWebP(r io.ReaderAt) (*entity.MediaInfo, error) {
+	b, err := readAt(r, 0, 30, "webp")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &entity.MediaInfo{Format: entity.FormatWebP, Type: entity.MediaTypeImage}
+	switch string(b[12:16]) {
+	case "VP8 ":
+		if !bytes.Equal(b[23:26], []byte{0x9D, 0x01, 0x2A}) {
+			return nil, corrupt("webp", "has a broken VP8 frame")
+		}
+		info.Width = int(binary.LittleEndian.Uint16(b[26:28]) & 0x3FFF)
+		info.Height = int(binary.LittleEndian.Uint16(b[28:30]) & 0x3FFF)
+	case "VP8L":
+		if b[20] != 0x2F {
+			return nil, corrupt("webp", "has a broken VP8L header")
+		}
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		info.Width = int(bits&0x3FFF) + 1
+		info.Height = int(bits>>14&0x3FFF) + 1
+	case "VP8X":
+		info.Width = int(uint24(b[24:27])) + 1
+		info.Height = int(uint24(b[27:30])) + 1
+	default:
+		return nil, corrupt("webp", "has an unknown bitstream")
+	}
+
+	if info.Width == 0 || info.Height == 0 {
+		return nil, corrupt("webp", "has no dimensions")
+	}
+	return info, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// walkRIFF calls fn with the ID, data offset and size of each chunk between
+// start and end, until fn returns false.
+func walkRIFF(r io.ReaderAt, start, end int64, format string, fn func(id string, off, size int64) (bool, error)) error {
+	for off := start; off+8 <= end; {
+		b, err := readAt(r, off, 8, format)
+		if err != nil {
+			return err
+		}
+		size := int64(binary.LittleEndian.Uint32(b[4:8]))
+
+		more, err := fn(string(b[:4]), off+8, size)
+		if err != nil || !more {
+			return err
+		}
+		off += 8 + size + size%2
+	}
+	return nil
+}
+
+// aviVideoCodecs maps BITMAPINFOHEADER compression FourCCs to codec names.
+var aviVideoCodecs = map[string]string{
+	"H264": "h264", "X264": "h264", "AVC1": "h264",
+	"HEVC": "hevc", "H265": "hevc",
+	"XVID": "mpeg4", "DIVX": "mpeg4", "DX50": "mpeg4", "FMP4": "mpeg4",
+	"MJPG": "mjpeg",
+}
+
+// wavCodecs maps WAVEFORMATEX format tags to codec names.
+var wavCodecs = map[uint16]string{
+	0x0001: "pcm",
+	0x0003: "pcm",
+	0xFFFE: "pcm", // WAVE_FORMAT_EXTENSIBLE, nearly always PCM
+	0x0055: "mp3",
+	0x00FF: "aac",
+	0x1610: "aac",
+	0x2000: "ac3",
+}
+
+func inspectAVI(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	var info *entity.MediaInfo
+	var videoCodec, audioCodec string
+	hasAudio := false
+
+	err := walkRIFF(r, 12, size, "avi", func(id string, off, chunkSize int64) (bool, error) {
+		if id != "LIST" {
+			return true, nil
+		}
+		listType, err := readAt(r, off, 4, "avi")
+		if err != nil || string(listType) != "hdrl" {
+			return err == nil, err
+		}
+
+		return false, walkRIFF(r, off+4, off+chunkSize, "avi", func(id string, off, chunkSize int64) (bool, error) {
+			switch id {
+			case "avih":
+				h, err := readAt(r, off, 40, "avi")
+				if err != nil {
+					return false, err
+				}
+				microSecPerFrame := binary.LittleEndian.Uint32(h[0:4])
+				totalFrames := binary.LittleEndian.Uint32(h[16:20])
+				info = &entity.MediaInfo{
+					Format:   entity.FormatAVI,
+					Type:     entity.MediaTypeVideo,
+					Width:    int(binary.LittleEndian.Uint32(h[32:36])),
+					Height:   int(binary.LittleEndian.Uint32(h[36:40])),
+					Duration: float64(microSecPerFrame) * float64(totalFrames) / 1e6,
+				}
+			case "LIST":
+				streamType, codec, err := aviStream(r, off, chunkSize)
+				if err != nil {
+					return false, err
+				}
+				switch {
+				case streamType == "vids" && videoCodec == "":
+					videoCodec = codec
+				case streamType == "auds" && !hasAudio:
+					hasAudio, audioCodec = true, codec
+				}
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info == nil || info.Width == 0 || info.Height == 0 {
+		return nil, corrupt("avi", "has no main header")
+	}
+	info.VideoCodec, info.AudioCodec, info.HasAudio = videoCodec, audioCodec, hasAudio
+	return info, nil
+}
+
+// aviStream reads the type ("vids", "auds", ...) and codec of a strl list
+// from its stream header and format chunks.
+func aviStream(r io.ReaderAt, off, size int64) (streamType, codec string, err error) {
+	listType, err := readAt(r, off, 4, "avi")
+	if err != nil || string(listType) != "strl" {
+		return "", "", err
+	}
+
+	err = walkRIFF(r, off+4, off+size, "avi", func(id string, off, chunkSize int64) (bool, error) {
+		switch id {
+		case "strh":
+			b, err := readAt(r, off, 4, "avi")
+			if err != nil {
+				return false, err
+			}
+			streamType = string(b)
+		case "strf":
+			switch streamType {
+			case "vids":
+				b, err := readAt(r, off, 20, "avi")
+				if err != nil {
+					return false, err
+				}
+				fourCC := strings.ToUpper(string(b[16:20]))
+				if name, ok := aviVideoCodecs[fourCC]; ok {
+					codec = name
+				} else {
+					codec = strings.ToLower(strings.TrimRight(fourCC, " \x00"))
+				}
+			case "auds":
+				b, err := readAt(r, off, 2, "avi")
+				if err != nil {
+					return false, err
+				}
+				codec = wavCodecs[binary.LittleEndian.Uint16(b)]
+			}
+			return false, nil
+		}
+		return true, nil
+	})
+	return streamType, codec, err
+}
+
+func inspectWAV(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	var byteRate uint32
+	var codec string
+	var dataSize int64 = -1
+	err := walkRIFF(r, 12, size, "wav", func(id string, off, chunkSize int64) (bool, error) {
+		switch id {
+		case "fmt ":
+			f, err := readAt(r, off, 16, "wav")
+			if err != nil {
+				return false, err
+			}
+			codec = wavCodecs[binary.LittleEndian.Uint16(f[0:2])]
+			byteRate = binary.LittleEndian.Uint32(f[8:12])
+		case "data":
+			// Streamed files may leave the size unset.
+			dataSize = min(chunkSize, size-off)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if byteRate == 0 || dataSize < 0 {
+		return nil, corrupt("wav", "has no format or data chunk")
+	}
+
+	return &entity.MediaInfo{
+		Format:     entity.FormatWAV,
+		Type:       entity.MediaTypeAudio,
+		Duration:   float64(dataSize) / float64(byteRate),
+		AudioCodec: codec,
+		HasAudio:   true,
+	}, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+// maxMovieBoxSize caps how much of an MP4/MOV index is read into memory.
+const maxMovieBoxSize = 64 << 20
+
+// isTopLevelBox reports whether typ starts an ISO BMFF (MP4, MOV, M4A)
+// file. QuickTime files may lack a leading ftyp box.
+func isTopLevelBox(typ string) bool {
+	switch typ {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot":
+		return true
+	default:
+		return false
+	}
+}
+
+// walkBoxes calls fn with the type, data offset and data size of each box
+// between start and end.
+func walkBoxes(r io.ReaderAt, start, end int64, fn func(typ string, off, size int64) error) error {
+	for off := start; off+8 <= end; {
+		b, err := readAt(r, off, 8, "mp4")
+		if err != nil {
+			return err
+		}
+		size, header := int64(binary.BigEndian.Uint32(b[:4])), int64(8)
+		switch size {
+		case 0: // extends to the end
+			size = end - off
+		case 1:
+			large, err := readAt(r, off+8, 8, "mp4")
+			if err != nil {
+				return err
+			}
+			size, header = int64(binary.BigEndian.Uint64(large)), 16
+		}
+		if size < header || size > end-off {
+			return corrupt("mp4", "has a box past the end of the file")
+		}
+
+		if err := fn(string(b[4:8]), off+header, size-header); err != nil {
+			return err
+		}
+		off += size
+	}
+	return nil
+}
+
+func inspectBMFF(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	info := &entity.MediaInfo{Format: entity.FormatMOV}
+	var moov []byte
+
+	err := walkBoxes(r, 0, size, func(typ string, off, boxSize int64) error {
+		switch typ {
+		case "ftyp":
+			brand, err := readAt(r, off, 4, "mp4")
+			if err != nil {
+				return err
+			}
+			info.Format = bmffFormat(string(brand))
+		case "moov":
+			if boxSize > maxMovieBoxSize {
+				return corrupt("mp4", "has an oversized moov box")
+			}
+			var err error
+			moov, err = readAt(r, off, int(boxSize), "mp4")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if moov == nil {
+		return nil, corrupt(info.Format, "has no moov box")
+	}
+
+	if err := parseMovie(bytes.NewReader(moov), int64(len(moov)), info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func bmffFormat(brand string) string {
+	switch brand {
+	case "qt  ":
+		return entity.FormatMOV
+	case "M4A ", "M4B ", "M4P ":
+		return entity.FormatM4A
+	default:
+		return entity.FormatMP4
+	}
+}
+
+// bmffCodecs maps sample entry types to codec names.
+var bmffCodecs = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc",
+	"av01": "av1",
+	"vp08": "vp8",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"jpeg": "mjpeg",
+	"apch": "prores", "apcn": "prores", "apcs": "prores", "apco": "prores", "ap4h": "prores", "ap4x": "prores",
+	"mp4a": "aac",
+	".mp3": "mp3",
+	"Opus": "opus",
+	"fLaC": "flac",
+	"alac": "alac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"lpcm": "pcm", "sowt": "pcm", "twos": "pcm", "in24": "pcm", "in32": "pcm", "fl32": "pcm", "fl64": "pcm",
+}
+
+// bmffTrack is what parseTrack reads from a trak box.
+type bmffTrack struct {
+	handler string // "vide", "soun", ...
+	width   int
+	height  int
+	codec   string
+}
+
+// parseMovie reads the duration from mvhd and the type, size and codec of
+// each trak in a moov box.
+func parseMovie(moov io.ReaderAt, size int64, info *entity.MediaInfo) error {
+	err := walkBoxes(moov, 0, size, func(typ string, off, boxSize int64) error {
+		switch typ {
+		case "mvhd":
+			duration, err := movieDuration(moov, off)
+			if err != nil {
+				return err
+			}
+			info.Duration = duration
+		case "trak":
+			track, err := parseTrack(moov, off, boxSize)
+			if err != nil {
+				return err
+			}
+			switch {
+			case track.handler == "vide" && track.width > 0 && track.height > 0 && info.Type != entity.MediaTypeVideo:
+				info.Type, info.Width, info.Height = entity.MediaTypeVideo, track.width, track.height
+				info.VideoCodec = track.codec
+			case track.handler == "soun" && !info.HasAudio:
+				info.HasAudio, info.AudioCodec = true, track.codec
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if info.Width == 0 {
+		if !info.HasAudio {
+			return corrupt(info.Format, "has no audio or video track")
+		}
+		info.Type = entity.MediaTypeAudio
+	}
+	return nil
+}
+
+func movieDuration(r io.ReaderAt, off int64) (float64, error) {
+	b, err := readAt(r, off, 32, "mp4")
+	if err != nil {
+		return 0, err
+	}
+
+	var timescale uint32
+	var duration uint64
+	if b[0] == 1 {
+		timescale = binary.BigEndian.Uint32(b[20:24])
+		duration = binary.BigEndian.Uint64(b[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(b[12:16])
+		duration = uint64(binary.BigEndian.Uint32(b[16:20]))
+	}
+	if timescale == 0 {
+		return 0, corrupt("mp4", "has no timescale")
+	}
+	return float64(duration) / float64(timescale), nil
+}
+
+// parseTrack returns a trak's handler type, its presentation size from tkhd
+// and the codec of its first sample description.
+func parseTrack(r io.ReaderAt, start, size int64) (track bmffTrack, err error) {
+	err = walkBoxes(r, start, start+size, func(typ string, off, boxSize int64) error {
+		switch typ {
+		case "tkhd":
+			version, err := readAt(r, off, 1, "mp4")
+			if err != nil {
+				return err
+			}
+			// Width and height are 16.16 fixed point after the matrix.
+			pos := 76
+			if version[0] == 1 {
+				pos = 88
+			}
+			b, err := readAt(r, off, pos+8, "mp4")
+			if err != nil {
+				return err
+			}
+			track.width = int(binary.BigEndian.Uint32(b[pos:pos+4]) >> 16)
+			track.height = int(binary.BigEndian.Uint32(b[pos+4:pos+8]) >> 16)
+		case "mdia":
+			return walkBoxes(r, off, off+boxSize, func(typ string, off, boxSize int64) error {
+				switch typ {
+				case "hdlr":
+					b, err := readAt(r, off, 12, "mp4")
+					if err != nil {
+						return err
+					}
+					track.handler = string(b[8:12])
+				case "minf":
+					codec, err := sampleCodec(r, off, boxSize)
+					if err != nil {
+						return err
+					}
+					track.codec = codec
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return track, err
+}
+
+// sampleCodec names the codec of the first sample entry in minf/stbl/stsd.
+func sampleCodec(r io.ReaderAt, start, size int64) (codec string, err error) {
+	err = walkBoxes(r, start, start+size, func(typ string, off, boxSize int64) error {
+		if typ != "stbl" {
+			return nil
+		}
+		return walkBoxes(r, off, off+boxSize, func(typ string, off, boxSize int64) error {
+			// A full box header and entry count precede the first entry.
+			if typ != "stsd" || boxSize < 16 {
+				return nil
+			}
+			b, err := readAt(r, off, 16, "mp4")
+			if err != nil {
+				return err
+			}
+			entry := string(b[12:16])
+			if name, ok := bmffCodecs[entry]; ok {
+				codec = name
+			} else {
+				codec = strings.ToLower(strings.TrimSpace(entry))
+			}
+			return nil
+		})
+	})
+	return codec, err
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+// EBML element IDs read from Matroska and WebM headers.
+const (
+	ebmlDocType        = 0x4282
+	mkvSegment         = 0x18538067
+	mkvInfo            = 0x1549A966
+	mkvTimecodeScale   = 0x2AD7B1
+	mkvDuration        = 0x4489
+	mkvTracks          = 0x1654AE6B
+	mkvTrackEntry      = 0xAE
+	mkvTrackType       = 0x83
+	mkvCodecID         = 0x86
+	mkvVideo           = 0xE0
+	mkvPixelWidth      = 0xB0
+	mkvPixelHeight     = 0xBA
+	mkvCluster         = 0x1F43B675
+	mkvTrackTypeVideo  = 1
+	mkvTrackTypeAudio  = 2
+	maxMatroskaElement = 16 << 20
+)
+
+// matroskaCodecs maps CodecIDs to codec names. IDs missing here keep their
+// own name without the track type prefix.
+var matroskaCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_AV1":            "av1",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_MPEG4/ISO/SP":   "mpeg4",
+	"V_MJPEG":          "mjpeg",
+	"V_PRORES":         "prores",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_AAC":            "aac",
+	"A_MPEG/L3":        "mp3",
+	"A_FLAC":           "flac",
+	"A_ALAC":           "alac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+}
+
+func matroskaCodec(id string) string {
+	if name, ok := matroskaCodecs[id]; ok {
+		return name
+	}
+	switch {
+	case strings.HasPrefix(id, "A_AAC/"):
+		return "aac"
+	case strings.HasPrefix(id, "A_PCM/"):
+		return "pcm"
+	}
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(id, "V_"), "A_"))
+}
+
+// ebmlUnknownSize marks an element whose size is not recorded, as in live
+// streams; it extends to the end of its parent.
+const ebmlUnknownSize = -1
+
+// readElementHeader reads an EBML element ID and data size at off and
+// returns them with the offset of the element's data.
+func readElementHeader(r io.ReaderAt, off, end int64) (id uint64, dataOff, size int64, err error) {
+	if off >= end {
+		return 0, 0, 0, corrupt("matroska", "is truncated")
+	}
+	b := make([]byte, min(12, end-off))
+	n, _ := r.ReadAt(b, off)
+	b = b[:n]
+
+	id, idLen, ok := readVint(b, false)
+	if !ok {
+		return 0, 0, 0, corrupt("matroska", "has a broken element ID")
+	}
+	rawSize, sizeLen, ok := readVint(b[idLen:], true)
+	if !ok {
+		return 0, 0, 0, corrupt("matroska", "has a broken element size")
+	}
+
+	switch {
+	case rawSize == 1<<(7*sizeLen)-1:
+		// Only the elements that stream media may leave their size open.
+		if id != mkvSegment && id != mkvCluster {
+			return 0, 0, 0, corrupt("matroska", "has an element of unknown size")
+		}
+		size = ebmlUnknownSize
+	case rawSize > math.MaxInt64-uint64(end):
+		return 0, 0, 0, corrupt("matroska", "has a broken element size")
+	default:
+		size = int64(rawSize)
+	}
+	return id, off + int64(idLen+sizeLen), size, nil
+}
+
+// readVint decodes an EBML variable length integer. IDs keep their length
+// marker bit; sizes drop it.
+func readVint(b []byte, stripMarker bool) (uint64, int, bool) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, false
+	}
+	length := bits.LeadingZeros8(b[0]) + 1
+	if len(b) < length {
+		return 0, 0, false
+	}
+
+	value := uint64(b[0])
+	if stripMarker {
+		value &= 0xFF >> length
+	}
+	for _, c := range b[1:length] {
+		value = value<<8 | uint64(c)
+	}
+	return value, length, true
+}
+
+// walkEBML calls fn with the ID, data offset and size of each element
+// between start and end until fn returns false.
+func walkEBML(r io.ReaderAt, start, end int64, fn func(id uint64, off, size int64) (bool, error)) error {
+	for off := start; off < end; {
+		id, dataOff, size, err := readElementHeader(r, off, end)
+		if err != nil {
+			return err
+		}
+		more, err := fn(id, dataOff, size)
+		if err != nil || !more {
+			return err
+		}
+		if size == ebmlUnknownSize {
+			return nil
+		}
+		off = dataOff + size
+	}
+	return nil
+}
+
+func readEBMLUint(r io.ReaderAt, off, size int64) (uint64, error) {
+	if size > 8 {
+		return 0, corrupt("matroska", "has an oversized integer")
+	}
+	b, err := readAt(r, off, int(size), "matroska")
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value, nil
+}
+
+func readEBMLFloat(r io.ReaderAt, off, size int64) (float64, error) {
+	if size != 4 && size != 8 {
+		return 0, corrupt("matroska", "has a broken float")
+	}
+	b, err := readAt(r, off, int(size), "matroska")
+	if err != nil {
+		return 0, err
+	}
+	if size == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+// readElement loads a whole element into memory for walking its children.
+func readElement(r io.ReaderAt, off, size int64) (*bytes.Reader, int64, error) {
+	if size == ebmlUnknownSize || size > maxMatroskaElement {
+		return nil, 0, corrupt("matroska", "has an oversized header element")
+	}
+	b, err := readAt(r, off, int(size), "matroska")
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(b), size, nil
+}
+
+func inspectMatroska(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	_, headerOff, headerSize, err := readElementHeader(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	if headerSize == ebmlUnknownSize {
+		return nil, corrupt("matroska", "has a broken EBML header")
+	}
+
+	info := &entity.MediaInfo{Format: entity.FormatMatroska}
+	err = walkEBML(r, headerOff, headerOff+headerSize, func(id uint64, off, size int64) (bool, error) {
+		if id == ebmlDocType {
+			docType, err := readAt(r, off, int(min(size, 16)), "matroska")
+			if err != nil {
+				return false, err
+			}
+			if string(bytes.TrimRight(docType, "\x00")) == "webm" {
+				info.Format = entity.FormatWebM
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	segmentID, segmentOff, segmentSize, err := readElementHeader(r, headerOff+headerSize, size)
+	if err != nil {
+		return nil, err
+	}
+	if segmentID != mkvSegment {
+		return nil, corrupt(info.Format, "has no segment")
+	}
+	segmentEnd := size
+	if segmentSize != ebmlUnknownSize {
+		segmentEnd = min(segmentOff+segmentSize, size)
+	}
+
+	timecodeScale, duration := uint64(1000000), 0.0
+	hasVideo, seenInfo, seenTracks := false, false, false
+
+	// Info and Tracks precede the first cluster.
+	err = walkEBML(r, segmentOff, segmentEnd, func(id uint64, off, elementSize int64) (bool, error) {
+		switch id {
+		case mkvInfo:
+			seenInfo = true
+			element, end, err := readElement(r, off, elementSize)
+			if err != nil {
+				return false, err
+			}
+			err = walkEBML(element, 0, end, func(id uint64, off, size int64) (bool, error) {
+				var err error
+				switch id {
+				case mkvTimecodeScale:
+					timecodeScale, err = readEBMLUint(element, off, size)
+				case mkvDuration:
+					duration, err = readEBMLFloat(element, off, size)
+				}
+				return err == nil, err
+			})
+			if err != nil {
+				return false, err
+			}
+		case mkvTracks:
+			seenTracks = true
+			element, end, err := readElement(r, off, elementSize)
+			if err != nil {
+				return false, err
+			}
+			err = walkEBML(element, 0, end, func(id uint64, off, size int64) (bool, error) {
+				if id != mkvTrackEntry {
+					return true, nil
+				}
+				track, err := parseMatroskaTrack(element, off, size)
+				switch {
+				case err != nil:
+					return false, err
+				case track.trackType == mkvTrackTypeVideo && track.width > 0 && track.height > 0 && !hasVideo:
+					hasVideo, info.Width, info.Height = true, track.width, track.height
+					info.VideoCodec = matroskaCodec(track.codecID)
+				case track.trackType == mkvTrackTypeAudio && !info.HasAudio:
+					info.HasAudio, info.AudioCodec = true, matroskaCodec(track.codecID)
+				}
+				return true, nil
+			})
+			if err != nil {
+				return false, err
+			}
+		case mkvCluster:
+			return false, nil
+		}
+		return !seenInfo || !seenTracks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case hasVideo:
+		info.Type = entity.MediaTypeVideo
+	case info.HasAudio:
+		info.Type = entity.MediaTypeAudio
+	default:
+		return nil, corrupt(info.Format, "has no audio or video track")
+	}
+	info.Duration = duration * float64(timecodeScale) / 1e9
+
+	return info, nil
+}
+
+// matroskaTrack is what parseMatroskaTrack reads from a TrackEntry.
+type matroskaTrack struct {
+	trackType uint64
+	codecID   string
+	width     int
+	height    int
+}
+
+func parseMatroskaTrack(r io.ReaderAt, start, size int64) (track matroskaTrack, err error) {
+	err = walkEBML(r, start, start+size, func(id uint64, off, size int64) (bool, error) {
+		var err error
+		switch id {
+		case mkvTrackType:
+			track.trackType, err = readEBMLUint(r, off, size)
+		case mkvCodecID:
+			var b []byte
+			b, err = readAt(r, off, int(min(size, 64)), "matroska")
+			track.codecID = string(bytes.TrimRight(b, "\x00"))
+		case mkvVideo:
+			err = walkEBML(r, off, off+size, func(id uint64, off, size int64) (bool, error) {
+				var value uint64
+				var err error
+				switch id {
+				case mkvPixelWidth:
+					value, err = readEBMLUint(r, off, size)
+					track.width = int(value)
+				case mkvPixelHeight:
+					value, err = readEBMLUint(r, off, size)
+					track.height = int(value)
+				}
+				return err == nil, err
+			})
+		}
+		return err == nil, err
+	})
+	return track, err
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+const (
+	// mpegSyncWindow is how far past any ID3 tag the first frame may start.
+	mpegSyncWindow = 64 << 10
+	// adtsSampleFrames is how many ADTS frames are read to estimate the
+	// bitrate of an AAC stream.
+	adtsSampleFrames = 64
+)
+
+var (
+	mp3Bitrates = [2][15]int{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}, // MPEG-1
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},     // MPEG-2 and 2.5
+	}
+	mp3SampleRates  = [3]int{44100, 48000, 32000}
+	adtsSampleRates = [13]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+)
+
+// mp3Frame is a parsed MPEG audio layer III frame header.
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int // kbit/s
+	sampleRate int
+	length     int
+	samples    int
+}
+
+func parseMP3Frame(b []byte) (mp3Frame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := b[1] >> 3 & 3 // 0: 2.5, 2: 2, 3: 1
+	layer := b[1] >> 1 & 3   // 1: layer III
+	bitrateIndex := b[2] >> 4
+	rateIndex := b[2] >> 2 & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{mpeg1: version == 3, mono: b[3]>>6 == 3}
+	f.sampleRate = mp3SampleRates[rateIndex]
+	table, coefficient := 0, 144
+	f.samples = 1152
+	if !f.mpeg1 {
+		table, coefficient = 1, 72
+		f.samples = 576
+		f.sampleRate /= 2
+		if version == 0 {
+			f.sampleRate /= 2
+		}
+	}
+	f.bitrate = mp3Bitrates[table][bitrateIndex]
+	f.length = coefficient*f.bitrate*1000/f.sampleRate + int(b[2]>>1&1)
+	return f, true
+}
+
+// adtsFrame is a parsed AAC ADTS frame header.
+type adtsFrame struct {
+	sampleRate int
+	length     int
+	samples    int
+}
+
+func parseADTSFrame(b []byte) (adtsFrame, bool) {
+	if len(b) < 7 || b[0] != 0xFF || b[1]&0xF6 != 0xF0 {
+		return adtsFrame{}, false
+	}
+	rateIndex := int(b[2] >> 2 & 0xF)
+	length := int(b[3]&3)<<11 | int(b[4])<<3 | int(b[5]>>5)
+	if rateIndex >= len(adtsSampleRates) || length < 7 {
+		return adtsFrame{}, false
+	}
+	return adtsFrame{
+		sampleRate: adtsSampleRates[rateIndex],
+		length:     length,
+		samples:    1024 * (int(b[6]&3) + 1),
+	}, true
+}
+
+// inspectMPEGAudio recognizes MP3 and raw AAC (ADTS) streams, skipping any
+// ID3v2 tag, by finding two consecutive valid frame headers.
+func inspectMPEGAudio(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	start := int64(0)
+	if tag, err := readAt(r, 0, 10, "mp3"); err == nil && string(tag[:3]) == "ID3" {
+		start = 10 + (int64(tag[6]&0x7F)<<21 | int64(tag[7]&0x7F)<<14 | int64(tag[8]&0x7F)<<7 | int64(tag[9]&0x7F))
+		if tag[5]&0x10 != 0 { // footer
+			start += 10
+		}
+	}
+
+	if size-start < 4 {
+		return nil, corrupt("mp3", "has no audio frames")
+	}
+	window := make([]byte, min(mpegSyncWindow, size-start))
+	n, _ := r.ReadAt(window, start)
+	window = window[:n]
+
+	for i := 0; i+4 <= len(window); i++ {
+		if window[i] != 0xFF {
+			continue
+		}
+		if frame, ok := parseMP3Frame(window[i:]); ok && followedByFrame(r, size, start+int64(i)+int64(frame.length), parseMP3Header) {
+			return mp3Info(r, size, start+int64(i), frame)
+		}
+		if frame, ok := parseADTSFrame(window[i:]); ok && followedByFrame(r, size, start+int64(i)+int64(frame.length), parseADTSHeader) {
+			return adtsInfo(r, size, start+int64(i))
+		}
+	}
+
+	return nil, ErrUnrecognizedFormat
+}
+
+func parseMP3Header(b []byte) (int, bool) {
+	f, ok := parseMP3Frame(b)
+	return f.length, ok
+}
+
+func parseADTSHeader(b []byte) (int, bool) {
+	f, ok := parseADTSFrame(b)
+	return f.length, ok
+}
+
+// followedByFrame reports whether another frame header starts at off, or
+// the stream ends there.
+func followedByFrame(r io.ReaderAt, size, off int64, parse func([]byte) (int, bool)) bool {
+	if off >= size-128 { // the stream may end in an ID3v1 tag
+		return off <= size
+	}
+	b := make([]byte, 7)
+	if _, err := r.ReadAt(b, off); err != nil {
+		return false
+	}
+	_, ok := parse(b)
+	return ok
+}
+
+// mp3Info takes the duration from a Xing/Info or VBRI header in the first
+// frame, and otherwise estimates it from the bitrate.
+func mp3Info(r io.ReaderAt, size, off int64, frame mp3Frame) (*entity.MediaInfo, error) {
+	info := &entity.MediaInfo{Format: entity.FormatMP3, Type: entity.MediaTypeAudio, AudioCodec: "mp3", HasAudio: true}
+
+	sideInfo := 32
+	switch {
+	case frame.mpeg1 && frame.mono:
+		sideInfo = 17
+	case !frame.mpeg1 && !frame.mono:
+		sideInfo = 17
+	case !frame.mpeg1 && frame.mono:
+		sideInfo = 9
+	}
+
+	if b, err := readAt(r, off+4+int64(sideInfo), 12, "mp3"); err == nil {
+		tag := string(b[:4])
+		if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(b[4:8])&1 != 0 {
+			frames := binary.BigEndian.Uint32(b[8:12])
+			info.Duration = float64(frames) * float64(frame.samples) / float64(frame.sampleRate)
+			return info, nil
+		}
+	}
+	if b, err := readAt(r, off+36, 18, "mp3"); err == nil && string(b[:4]) == "VBRI" {
+		frames := binary.BigEndian.Uint32(b[14:18])
+		info.Duration = float64(frames) * float64(frame.samples) / float64(frame.sampleRate)
+		return info, nil
+	}
+
+	end := size
+	if size-off > 128 {
+		if tag, err := readAt(r, size-128, 3, "mp3"); err == nil && string(tag) == "TAG" {
+			end -= 128
+		}
+	}
+	info.Duration = float64(end-off) * 8 / float64(frame.bitrate*1000)
+	return info, nil
+}
+
+// adtsInfo estimates the duration of an AAC stream from the average bitrate
+// of its first frames, as ADTS has no index.
+func adtsInfo(r io.ReaderAt, size, start int64) (*entity.MediaInfo, error) {
+	var bytesRead int64
+	var seconds float64
+	off := start
+	for range adtsSampleFrames {
+		b := make([]byte, 7)
+		if _, err := r.ReadAt(b, off); err != nil {
+			break
+		}
+		frame, ok := parseADTSFrame(b)
+		if !ok {
+			break
+		}
+		bytesRead += int64(frame.length)
+		seconds += float64(frame.samples) / float64(frame.sampleRate)
+		off += int64(frame.length)
+	}
+
+	info := &entity.MediaInfo{Format: entity.FormatAAC, Type: entity.MediaTypeAudio, AudioCodec: "aac", HasAudio: true}
+	if bytesRead > 0 {
+		info.Duration = float64(size-start) / float64(bytesRead) * seconds
+	}
+	return info, nil
+}
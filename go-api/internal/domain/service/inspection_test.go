@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+// matroskaUnknownSizeCrash once made readAt allocate a negative length: an
+// EBML header whose all-ones size means "unknown".
+var matroskaUnknownSizeCrash = []byte("\x1aEߣ\x84B\x82\xff")
+
+func TestInspectMedia(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want entity.MediaInfo
+	}{
+		{"png", encodePNG(t, 3, 2), entity.MediaInfo{Format: entity.FormatPNG, Type: entity.MediaTypeImage, Width: 3, Height: 2}},
+		{"jpeg", encodeJPEG(t, 4, 3), entity.MediaInfo{Format: entity.FormatJPEG, Type: entity.MediaTypeImage, Width: 4, Height: 3}},
+		{"webp vp8x", webpVP8X(640, 480), entity.MediaInfo{Format: entity.FormatWebP, Type: entity.MediaTypeImage, Width: 640, Height: 480}},
+		{"webp vp8l", webpVP8L(17, 9), entity.MediaInfo{Format: entity.FormatWebP, Type: entity.MediaTypeImage, Width: 17, Height: 9}},
+		{"wav", wavFile(16000, 8000), entity.MediaInfo{Format: entity.FormatWAV, Type: entity.MediaTypeAudio, Duration: 0.5, AudioCodec: "pcm", HasAudio: true}},
+		{"avi", aviFile(), entity.MediaInfo{Format: entity.FormatAVI, Type: entity.MediaTypeVideo, Width: 320, Height: 240, Duration: 1, VideoCodec: "h264", AudioCodec: "pcm", HasAudio: true}},
+		{"mp4", mp4File("isom", true), entity.MediaInfo{Format: entity.FormatMP4, Type: entity.MediaTypeVideo, Width: 1280, Height: 720, Duration: 2.5, VideoCodec: "h264", AudioCodec: "aac", HasAudio: true}},
+		{"m4a", mp4File("M4A ", false), entity.MediaInfo{Format: entity.FormatM4A, Type: entity.MediaTypeAudio, Duration: 2.5, AudioCodec: "aac", HasAudio: true}},
+		{"webm", matroskaFile("webm", false), entity.MediaInfo{Format: entity.FormatWebM, Type: entity.MediaTypeVideo, Width: 320, Height: 240, Duration: 1.5, VideoCodec: "vp9", AudioCodec: "opus", HasAudio: true}},
+		{"matroska live", matroskaFile("matroska", true), entity.MediaInfo{Format: entity.FormatMatroska, Type: entity.MediaTypeVideo, Width: 320, Height: 240, Duration: 1.5, VideoCodec: "vp9", AudioCodec: "opus", HasAudio: true}},
+		{"mp3", mp3File(false), entity.MediaInfo{Format: entity.FormatMP3, Type: entity.MediaTypeAudio, Duration: 0.260625, AudioCodec: "mp3", HasAudio: true}},
+		{"mp3 with id3", mp3File(true), entity.MediaInfo{Format: entity.FormatMP3, Type: entity.MediaTypeAudio, Duration: 0.260625, AudioCodec: "mp3", HasAudio: true}},
+		{"aac", adtsFile(20), entity.MediaInfo{Format: entity.FormatAAC, Type: entity.MediaTypeAudio, Duration: 20 * 1024.0 / 44100, AudioCodec: "aac", HasAudio: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := InspectMedia(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err != nil {
+				t.Fatalf("InspectMedia() error = %v", err)
+			}
+			got := *info
+			if math.Abs(got.Duration-tt.want.Duration) < 1e-6 {
+				got.Duration = tt.want.Duration
+			}
+			if got != tt.want {
+				t.Errorf("InspectMedia() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectMediaRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrUnrecognizedFormat},
+		{"text", []byte("hello, world"), ErrUnrecognizedFormat},
+		{"truncated png", encodePNG(t, 3, 2)[:20], ErrCorruptMedia},
+		{"truncated jpeg", encodeJPEG(t, 4, 3)[:30], ErrCorruptMedia},
+		{"truncated webp", webpVP8X(640, 480)[:20], ErrCorruptMedia},
+		{"truncated wav", wavFile(16000, 8000)[:40], ErrCorruptMedia},
+		{"truncated avi", aviFile()[:60], ErrCorruptMedia},
+		{"truncated mp4", truncate(mp4File("isom", true), 100), ErrCorruptMedia},
+		{"truncated webm", truncate(matroskaFile("webm", false), 60), ErrCorruptMedia},
+		{"id3 without frames", append(id3Tag(), make([]byte, 64)...), ErrUnrecognizedFormat},
+		{"matroska unknown size header", matroskaUnknownSizeCrash, ErrCorruptMedia},
+		{"matroska unknown size info", matroskaUnknownSizeInfo(), ErrCorruptMedia},
+		{"mp4 oversized large box", mp4OversizedBox(), ErrCorruptMedia},
+		{"jpeg without frame header", []byte{0xFF, 0xD8, 0xFF, 0xD9, 0, 0}, ErrCorruptMedia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := InspectMedia(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("InspectMedia() = %+v, %v, want %v", info, err, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzInspectMedia(f *testing.F) {
+	for _, seed := range [][]byte{
+		encodePNG(f, 3, 2),
+		encodeJPEG(f, 4, 3),
+		webpVP8X(640, 480),
+		webpVP8L(17, 9),
+		wavFile(16000, 8000),
+		aviFile(),
+		mp4File("isom", true),
+		matroskaFile("webm", false),
+		matroskaFile("matroska", true),
+		mp3File(true),
+		adtsFile(4),
+		matroskaUnknownSizeCrash,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := InspectMedia(bytes.NewReader(data), int64(len(data)))
+		switch {
+		case err != nil:
+			if !errors.Is(err, ErrUnrecognizedFormat) && !errors.Is(err, ErrCorruptMedia) {
+				t.Fatalf("InspectMedia() error = %v, want unrecognized or corrupt", err)
+			}
+		case info == nil:
+			t.Fatal("InspectMedia() returned neither info nor error")
+		}
+	})
+}
+
+func truncate(b []byte, n int) []byte {
+	return b[:min(n, len(b))]
+}
+
+func encodePNG(tb testing.TB, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(tb testing.TB, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func le16(v int) []byte { return binary.LittleEndian.AppendUint16(nil, uint16(v)) }
+func le32(v int) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(v)) }
+func be16(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func be32(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// riffChunk builds a RIFF chunk, padded to an even size.
+func riffChunk(id string, data ...[]byte) []byte {
+	body := concat(data...)
+	chunk := concat([]byte(id), le32(len(body)), body)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riffList(listType string, chunks ...[]byte) []byte {
+	return riffChunk("LIST", append([][]byte{[]byte(listType)}, chunks...)...)
+}
+
+func riffFile(form string, chunks ...[]byte) []byte {
+	return riffChunk("RIFF", append([][]byte{[]byte(form)}, chunks...)...)
+}
+
+func webpVP8X(width, height int) []byte {
+	size := concat(le32(width - 1)[:3], le32(height - 1)[:3])
+	return riffFile("WEBP", riffChunk("VP8X", make([]byte, 4), size))
+}
+
+func webpVP8L(width, height int) []byte {
+	bits := (width - 1) | (height-1)<<14
+	return riffFile("WEBP", riffChunk("VP8L", []byte{0x2F}, le32(bits), make([]byte, 5)))
+}
+
+func wavFile(byteRate, dataSize int) []byte {
+	format := concat(le16(1), le16(1), le32(byteRate/2), le32(byteRate), le16(2), le16(16))
+	return riffFile("WAVE", riffChunk("fmt ", format), riffChunk("data", make([]byte, dataSize)))
+}
+
+func aviFile() []byte {
+	avih := make([]byte, 56)
+	copy(avih[0:], le32(40000)) // 25 fps
+	copy(avih[16:], le32(25))
+	copy(avih[32:], le32(320))
+	copy(avih[36:], le32(240))
+
+	video := make([]byte, 40)
+	copy(video[16:], "H264")
+
+	return riffFile("AVI ",
+		riffList("hdrl",
+			riffChunk("avih", avih),
+			riffList("strl", riffChunk("strh", []byte("vids"), make([]byte, 52)), riffChunk("strf", video)),
+			riffList("strl", riffChunk("strh", []byte("auds"), make([]byte, 52)), riffChunk("strf", le16(1), make([]byte, 14))),
+		),
+		riffList("movi"),
+	)
+}
+
+func box(typ string, data ...[]byte) []byte {
+	body := concat(data...)
+	return concat(be32(8+len(body)), []byte(typ), body)
+}
+
+func mp4Track(handler, sampleEntry string, width, height int) []byte {
+	tkhd := make([]byte, 84)
+	copy(tkhd[76:], be32(width<<16))
+	copy(tkhd[80:], be32(height<<16))
+
+	hdlr := concat(make([]byte, 8), []byte(handler), make([]byte, 12))
+	stsd := concat(make([]byte, 4), be32(1), be32(16), []byte(sampleEntry))
+
+	return box("trak",
+		box("tkhd", tkhd),
+		box("mdia",
+			box("hdlr", hdlr),
+			box("minf", box("stbl", box("stsd", stsd))),
+		),
+	)
+}
+
+func mp4File(brand string, video bool) []byte {
+	mvhd := make([]byte, 100)
+	copy(mvhd[12:], be32(1000))
+	copy(mvhd[16:], be32(2500))
+
+	moov := [][]byte{box("mvhd", mvhd)}
+	if video {
+		moov = append(moov, mp4Track("vide", "avc1", 1280, 720))
+	}
+	moov = append(moov, mp4Track("soun", "mp4a", 0, 0))
+
+	return concat(
+		box("ftyp", []byte(brand), be32(0), []byte("isom")),
+		box("moov", moov...),
+		box("mdat", make([]byte, 16)),
+	)
+}
+
+func mp4OversizedBox() []byte {
+	// A 64-bit box size that overflows the file offset.
+	return concat(be32(1), []byte("ftyp"), binary.BigEndian.AppendUint64(nil, math.MaxInt64), []byte("isom"))
+}
+
+// ebml builds an element with a one-byte size, or an eight-byte one for
+// larger data.
+func ebml(id []byte, data ...[]byte) []byte {
+	body := concat(data...)
+	if len(body) < 0x7F {
+		return concat(id, []byte{0x80 | byte(len(body))}, body)
+	}
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	return concat(id, size, body)
+}
+
+func matroskaFile(docType string, live bool) []byte {
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, []byte(docType)))
+
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(1500))
+	info := ebml([]byte{0x15, 0x49, 0xA9, 0x66},
+		ebml([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}),
+		ebml([]byte{0x44, 0x89}, duration),
+	)
+	tracks := ebml([]byte{0x16, 0x54, 0xAE, 0x6B},
+		ebml([]byte{0xAE},
+			ebml([]byte{0x83}, []byte{1}),
+			ebml([]byte{0x86}, []byte("V_VP9")),
+			ebml([]byte{0xE0}, ebml([]byte{0xB0}, be16(320)), ebml([]byte{0xBA}, be16(240))),
+		),
+		ebml([]byte{0xAE},
+			ebml([]byte{0x83}, []byte{2}),
+			ebml([]byte{0x86}, []byte("A_OPUS")),
+		),
+	)
+	cluster := concat([]byte{0x1F, 0x43, 0xB6, 0x75, 0xFF}, make([]byte, 8))
+
+	segmentID := []byte{0x18, 0x53, 0x80, 0x67}
+	if live {
+		return concat(header, segmentID, []byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, info, tracks, cluster)
+	}
+	return concat(header, ebml(segmentID, info, tracks, cluster))
+}
+
+func matroskaUnknownSizeInfo() []byte {
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, []byte("webm")))
+	return concat(header, ebml([]byte{0x18, 0x53, 0x80, 0x67}, []byte{0x15, 0x49, 0xA9, 0x66, 0xFF}, make([]byte, 16)))
+}
+
+func id3Tag() []byte {
+	return concat([]byte("ID3"), []byte{3, 0, 0, 0, 0, 0, 10}, make([]byte, 10))
+}
+
+func mp3File(withID3 bool) []byte {
+	// MPEG-1 layer III, 128 kbit/s, 44.1 kHz: 417 byte frames.
+	frame := concat([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413))
+	data := bytes.Repeat(frame, 10)
+	if withID3 {
+		data = concat(id3Tag(), data)
+	}
+	return data
+}
+
+func adtsFile(frames int) []byte {
+	// AAC LC, 44.1 kHz, stereo: 100 byte frames of 1024 samples.
+	frame := concat([]byte{0xFF, 0xF1, 0x50, 0x80, 100 >> 3, (100&7)<<5 | 0x1F, 0xFC}, make([]byte, 93))
+	return bytes.Repeat(frame, frames)
+}
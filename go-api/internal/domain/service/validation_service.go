@@ -1,15 +1,104 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/domain/entity"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Codes of validation errors, reported to clients with the field they
+// apply to.
+const (
+	CodeUnsupportedFormat  = "unsupported_format"
+	CodeFormatMismatch     = "format_mismatch"
+	CodeCorruptFile        = "corrupt_file"
+	CodeFileTooLarge       = "file_too_large"
+	CodeDurationExceeded   = "duration_exceeded"
+	CodeResolutionExceeded = "resolution_exceeded"
+	CodeInvalidOption      = "invalid_option"
+	CodeInvalidTimeline    = "invalid_timeline"
+	CodeInvalidRequest     = "invalid_request"
+	CodeMissingFile        = "missing_file"
+	CodeEmptyFile          = "empty_file"
 )
 
-type ValidationService struct{}
+// ValidationError rejects a request because of one of its fields: a job
+// option, or an uploaded file after looking at its content.
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func invalidOption(field, format string, args ...any) error {
+	return &ValidationError{Field: field, Code: CodeInvalidOption, Message: fmt.Sprintf(format, args...)}
+}
+
+// invalidTimeline reports a manifest problem. Errors about a single asset
+// are folded into it so clients see where in the manifest it happened.
+func invalidTimeline(format string, args ...any) error {
+	return &ValidationError{Field: "manifest", Code: CodeInvalidTimeline, Message: fmt.Sprintf(format, args...)}
+}
+
+func validationMessage(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return verr.Message
+	}
+	return err.Error()
+}
+
+// UploadLimits bounds accepted inputs per media type. Zero disables a limit.
+type UploadLimits struct {
+	MaxImageSize      int64
+	MaxVideoSize      int64
+	MaxAudioSize      int64
+	MaxVideoDuration  time.Duration
+	MaxAudioDuration  time.Duration
+	MaxImageDimension int
+	MaxVideoDimension int
+}
+
+// extensionFormats maps accepted file extensions to the media type and the
+// detected formats their content may have.
+var extensionFormats = map[string]struct {
+	mediaType entity.MediaType
+	formats   []string
+}{
+	".jpg":  {entity.MediaTypeImage, []string{entity.FormatJPEG}},
+	".jpeg": {entity.MediaTypeImage, []string{entity.FormatJPEG}},
+	".png":  {entity.MediaTypeImage, []string{entity.FormatPNG}},
+	".webp": {entity.MediaTypeImage, []string{entity.FormatWebP}},
+	".mp4":  {entity.MediaTypeVideo, []string{entity.FormatMP4, entity.FormatMOV, entity.FormatM4A}},
+	".mov":  {entity.MediaTypeVideo, []string{entity.FormatMOV, entity.FormatMP4}},
+	".mkv":  {entity.MediaTypeVideo, []string{entity.FormatMatroska, entity.FormatWebM}},
+	".webm": {entity.MediaTypeVideo, []string{entity.FormatWebM, entity.FormatMatroska}},
+	".avi":  {entity.MediaTypeVideo, []string{entity.FormatAVI}},
+	".mp3":  {entity.MediaTypeAudio, []string{entity.FormatMP3}},
+	".wav":  {entity.MediaTypeAudio, []string{entity.FormatWAV}},
+	".m4a":  {entity.MediaTypeAudio, []string{entity.FormatM4A, entity.FormatMP4}},
+	".aac":  {entity.MediaTypeAudio, []string{entity.FormatAAC}},
+}
+
+type ValidationService struct {
+	limits UploadLimits
+}
 
-func NewValidationService() *ValidationService {
-	return &ValidationService{}
+func NewValidationService(limits UploadLimits) *ValidationService {
+	return &ValidationService{limits: limits}
 }
 
 func (s *ValidationService) ValidateMediaFile(filename string) error {
@@ -22,7 +111,133 @@ func (s *ValidationService) ValidateMediaFile(filename string) error {
 		}
 	}
 
-	return fmt.Errorf("invalid media format: %s (allowed: jpg, png, webp, mp4, mov, avi, mkv, webm)", ext)
+	return &ValidationError{Field: "media", Code: CodeUnsupportedFormat,
+		Message: fmt.Sprintf("invalid media format: %s (allowed: jpg, png, webp, mp4, mov, avi, mkv, webm)", ext)}
+}
+
+func (s *ValidationService) ValidatePreset(name string) error {
+	if _, ok := entity.LookupPreset(name); ok {
+		return nil
+	}
+
+	return invalidOption("preset", "invalid preset: %s (allowed: %s)", name, strings.Join(entity.PresetNames(), ", "))
+}
+
+func (s *ValidationService) ValidateOutputMode(mode, presetName string) error {
+	switch mode {
+	case entity.OutputModeFile:
+		return nil
+	case entity.OutputModeHLS, entity.OutputModeHLSDASH:
+		if preset, ok := entity.LookupPreset(presetName); ok && preset.Streamable {
+			return nil
+		}
+		return invalidOption("output", "output mode %s requires the h264-mp4 or h265-mp4 preset", mode)
+	default:
+		return invalidOption("output", "invalid output mode: %s (allowed: file, hls, hls+dash)", mode)
+	}
+}
+
+// ValidateMotion checks a motion effect; motion only applies to image uploads.
+func (s *ValidationService) ValidateMotion(motion, mediaFilename string) error {
+	if !slices.Contains(entity.MotionNames(), motion) {
+		return invalidOption("motion", "invalid motion: %s (allowed: %s)", motion, strings.Join(entity.MotionNames(), ", "))
+	}
+	if motion != entity.MotionNone && !isImageFile(mediaFilename) {
+		return invalidOption("motion", "motion %s needs an image upload", motion)
+	}
+	return nil
+}
+
+// ValidateVisualizer checks the visualizer options of an image upload.
+func (s *ValidationService) ValidateVisualizer(visualizer entity.Visualizer, mediaFilename, presetName string) error {
+	switch visualizer.Style {
+	case entity.VisualizerNone:
+		return nil
+	case entity.VisualizerWaveform, entity.VisualizerSpectrum, entity.VisualizerCircle:
+	default:
+		return invalidOption("visualizer", "invalid visualizer: %s (allowed: none, waveform, spectrum, circle)", visualizer.Style)
+	}
+
+	if !isImageFile(mediaFilename) {
+		return invalidOption("visualizer", "visualizer %s needs an image upload", visualizer.Style)
+	}
+	if preset, ok := entity.LookupPreset(presetName); ok && preset.AudioOnly {
+		return invalidOption("visualizer", "visualizer %s needs a video preset", visualizer.Style)
+	}
+
+	if visualizer.Color != "" && !hexColorPattern.MatchString(visualizer.Color) {
+		return invalidOption("visualizer_color", "invalid visualizer color: %s (expected #RRGGBB)", visualizer.Color)
+	}
+
+	switch visualizer.Position {
+	case "", entity.VisualizerTop, entity.VisualizerCenter, entity.VisualizerBottom:
+		return nil
+	default:
+		return invalidOption("visualizer_position", "invalid visualizer position: %s (allowed: top, center, bottom)", visualizer.Position)
+	}
+}
+
+func (s *ValidationService) ValidateSubtitleFile(filename string) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	validExts := []string{".srt", ".vtt", ".ass"}
+
+	for _, valid := range validExts {
+		if ext == valid {
+			return nil
+		}
+	}
+
+	return &ValidationError{Field: "subtitles", Code: CodeUnsupportedFormat,
+		Message: fmt.Sprintf("invalid subtitle format: %s (allowed: srt, vtt, ass)", ext)}
+}
+
+// ValidateSubtitles checks the subtitle options against the job's preset and output mode.
+func (s *ValidationService) ValidateSubtitles(subtitles entity.Subtitles, presetName, outputMode string) error {
+	if preset, ok := entity.LookupPreset(presetName); ok && preset.AudioOnly {
+		return invalidOption("subtitles", "subtitles need a video preset")
+	}
+
+	switch subtitles.Mode {
+	case entity.SubtitlesBurn:
+	case entity.SubtitlesSoft:
+		if entity.IsStreamingMode(outputMode) {
+			return invalidOption("subtitles_mode", "soft subtitles are not carried into %s output; burn them in instead", outputMode)
+		}
+		if subtitles.FontSize != 0 || subtitles.Color != "" {
+			return invalidOption("subtitles_mode", "subtitle styling only applies to burned-in subtitles")
+		}
+	default:
+		return invalidOption("subtitles_mode", "invalid subtitles mode: %s (allowed: burn, soft)", subtitles.Mode)
+	}
+
+	if subtitles.FontSize < 0 || subtitles.FontSize > 200 {
+		return invalidOption("subtitles_font_size", "invalid subtitles font size: %d", subtitles.FontSize)
+	}
+	if subtitles.Color != "" && !hexColorPattern.MatchString(subtitles.Color) {
+		return invalidOption("subtitles_color", "invalid subtitles color: %s (expected #RRGGBB)", subtitles.Color)
+	}
+
+	return nil
+}
+
+// ValidateLoudnorm checks loudness normalization targets. Zero targets are
+// left to the worker's defaults.
+func (s *ValidationService) ValidateLoudnorm(loudnorm entity.Loudnorm) error {
+	if !loudnorm.Enabled {
+		if loudnorm.Integrated != 0 || loudnorm.TruePeak != 0 {
+			return invalidOption("loudnorm", "loudness targets need loudnorm enabled")
+		}
+		return nil
+	}
+
+	if loudnorm.Integrated != 0 && (loudnorm.Integrated < -70 || loudnorm.Integrated > -5) {
+		return invalidOption("loudnorm_target", "invalid loudness target: %g LUFS (allowed: -70 to -5)", loudnorm.Integrated)
+	}
+	if loudnorm.TruePeak < -9 || loudnorm.TruePeak > 0 {
+		return invalidOption("loudnorm_true_peak", "invalid true peak: %g dBTP (allowed: -9 to 0)", loudnorm.TruePeak)
+	}
+
+	return nil
 }
 
 func (s *ValidationService) ValidateAudioFile(filename string) error {
@@ -35,5 +250,172 @@ func (s *ValidationService) ValidateAudioFile(filename string) error {
 		}
 	}
 
-	return fmt.Errorf("invalid audio format: %s (allowed: mp3, wav, m4a, aac)", ext)
+	return &ValidationError{Field: "audio", Code: CodeUnsupportedFormat,
+		Message: fmt.Sprintf("invalid audio format: %s (allowed: mp3, wav, m4a, aac)", ext)}
+}
+
+// ValidateSize checks a file size announced before upload against the
+// limit for the media type its extension implies.
+func (s *ValidationService) ValidateSize(field, filename string, size int64) error {
+	ext, ok := extensionFormats[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return &ValidationError{Field: field, Code: CodeUnsupportedFormat, Message: fmt.Sprintf("%s has an unsupported extension", filename)}
+	}
+	return s.checkSize(field, filename, ext.mediaType, size)
+}
+
+// ValidateContent inspects an uploaded file and checks that its content is
+// the format its extension claims and within the limits for its type.
+func (s *ValidationService) ValidateContent(field, filename string, r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	ext, ok := extensionFormats[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return nil, &ValidationError{Field: field, Code: CodeUnsupportedFormat, Message: fmt.Sprintf("%s has an unsupported extension", filename)}
+	}
+	if err := s.checkSize(field, filename, ext.mediaType, size); err != nil {
+		return nil, err
+	}
+
+	info, err := InspectMedia(r, size)
+	switch {
+	case errors.Is(err, ErrUnrecognizedFormat):
+		return nil, &ValidationError{Field: field, Code: CodeUnsupportedFormat, Message: fmt.Sprintf("%s is not a supported image, video or audio file", filename)}
+	case errors.Is(err, ErrCorruptMedia):
+		return nil, &ValidationError{Field: field, Code: CodeCorruptFile, Message: fmt.Sprintf("%s: %v", filename, err)}
+	case err != nil:
+		return nil, fmt.Errorf("failed to inspect %s: %w", filename, err)
+	}
+
+	if !slices.Contains(ext.formats, info.Format) || info.Type != ext.mediaType {
+		return nil, &ValidationError{Field: field, Code: CodeFormatMismatch,
+			Message: fmt.Sprintf("%s contains %s %s, not the %s its extension claims", filename, info.Format, info.Type, ext.mediaType)}
+	}
+
+	maxDuration, maxDimension := s.limits.MaxAudioDuration, 0
+	switch info.Type {
+	case entity.MediaTypeImage:
+		maxDuration, maxDimension = 0, s.limits.MaxImageDimension
+	case entity.MediaTypeVideo:
+		maxDuration, maxDimension = s.limits.MaxVideoDuration, s.limits.MaxVideoDimension
+	}
+	if maxDimension > 0 && (info.Width > maxDimension || info.Height > maxDimension) {
+		return nil, &ValidationError{Field: field, Code: CodeResolutionExceeded,
+			Message: fmt.Sprintf("%s is %dx%d (max %d pixels per side)", filename, info.Width, info.Height, maxDimension)}
+	}
+	if maxDuration > 0 && info.Duration > maxDuration.Seconds() {
+		return nil, &ValidationError{Field: field, Code: CodeDurationExceeded,
+			Message: fmt.Sprintf("%s is %s long (max %s)", filename, time.Duration(info.Duration*float64(time.Second)).Round(time.Second), maxDuration)}
+	}
+
+	return info, nil
+}
+
+func (s *ValidationService) checkSize(field, filename string, mediaType entity.MediaType, size int64) error {
+	limit := s.limits.MaxAudioSize
+	switch mediaType {
+	case entity.MediaTypeImage:
+		limit = s.limits.MaxImageSize
+	case entity.MediaTypeVideo:
+		limit = s.limits.MaxVideoSize
+	}
+	if limit > 0 && size > limit {
+		return &ValidationError{Field: field, Code: CodeFileTooLarge,
+			Message: fmt.Sprintf("%s is %d MiB (max %d MiB for %s)", filename, size>>20, limit>>20, mediaType)}
+	}
+	return nil
+}
+
+const (
+	maxTimelineItems     = 100
+	maxTimelineDimension = 3840
+)
+
+// ValidateTimeline checks a timeline manifest against the uploaded asset names.
+func (s *ValidationService) ValidateTimeline(timeline *entity.Timeline, assets []string) error {
+	if len(timeline.Items) == 0 {
+		return invalidTimeline("timeline has no items")
+	}
+	if len(timeline.Items) > maxTimelineItems {
+		return invalidTimeline("timeline has %d items (max %d)", len(timeline.Items), maxTimelineItems)
+	}
+
+	if (timeline.Width == 0) != (timeline.Height == 0) {
+		return invalidTimeline("timeline width and height must be set together")
+	}
+	if timeline.Width < 0 || timeline.Height < 0 || timeline.Width > maxTimelineDimension || timeline.Height > maxTimelineDimension {
+		return invalidTimeline("timeline size %dx%d out of range (max %d)", timeline.Width, timeline.Height, maxTimelineDimension)
+	}
+
+	uploaded := make(map[string]bool, len(assets))
+	for _, name := range assets {
+		uploaded[name] = true
+	}
+	used := make(map[string]bool, len(assets))
+
+	checkAsset := func(name string) error {
+		if name == "" || name != filepath.Base(name) || strings.Contains(name, "..") {
+			return fmt.Errorf("invalid asset name: %q", name)
+		}
+		if !uploaded[name] {
+			return fmt.Errorf("asset %s was not uploaded", name)
+		}
+		used[name] = true
+		return nil
+	}
+
+	for i, item := range timeline.Items {
+		if err := checkAsset(item.Asset); err != nil {
+			return invalidTimeline("item %d: %v", i, err)
+		}
+		if err := s.ValidateMediaFile(item.Asset); err != nil {
+			return invalidTimeline("item %d: %s", i, validationMessage(err))
+		}
+		if item.Duration < 0 || item.Start < 0 || item.TransitionDuration < 0 {
+			return invalidTimeline("item %d: durations must not be negative", i)
+		}
+		if isImageFile(item.Asset) && item.Duration == 0 {
+			return invalidTimeline("item %d: image %s needs a duration", i, item.Asset)
+		}
+
+		switch item.Transition {
+		case "", entity.TransitionCut:
+		case entity.TransitionCrossfade:
+			if item.Duration > 0 && item.TransitionDuration >= item.Duration {
+				return invalidTimeline("item %d: crossfade must be shorter than the item", i)
+			}
+		default:
+			return invalidTimeline("item %d: invalid transition: %s (allowed: cut, crossfade)", i, item.Transition)
+		}
+	}
+
+	for _, track := range []*entity.TimelineAudio{timeline.Music, timeline.Voiceover} {
+		if track == nil {
+			continue
+		}
+		if err := checkAsset(track.Asset); err != nil {
+			return invalidTimeline("%v", err)
+		}
+		if err := s.ValidateAudioFile(track.Asset); err != nil {
+			return invalidTimeline("track %s: %s", track.Asset, validationMessage(err))
+		}
+		if track.Volume < 0 || track.Start < 0 {
+			return invalidTimeline("track %s: volume and start must not be negative", track.Asset)
+		}
+	}
+
+	for _, name := range assets {
+		if !used[name] {
+			return invalidTimeline("asset %s is not used by the timeline", name)
+		}
+	}
+
+	return nil
+}
+
+func isImageFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return true
+	default:
+		return false
+	}
 }
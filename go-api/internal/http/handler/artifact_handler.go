@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/go-chi/chi/v5"
+)
+
+type ArtifactHandler struct {
+	artifactUseCase *usecase.ArtifactUseCase
+}
+
+func NewArtifactHandler(artifactUseCase *usecase.ArtifactUseCase) *ArtifactHandler {
+	return &ArtifactHandler{
+		artifactUseCase: artifactUseCase,
+	}
+}
+
+func (h *ArtifactHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobUUID := chi.URLParam(r, "uuid")
+
+	resp, err := h.artifactUseCase.List(ctx, jobUUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Download streams one artifact, addressed by its path under the job prefix.
+func (h *ArtifactHandler) Download(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobUUID := chi.URLParam(r, "uuid")
+	name := chi.URLParam(r, "*")
+
+	result, err := h.artifactUseCase.Download(ctx, jobUUID, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if result.Checksum != "" {
+		w.Header().Set("X-Checksum", result.Checksum)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Filename}))
+	serveDownload(w, r, result)
+}
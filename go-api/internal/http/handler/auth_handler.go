@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type AuthHandler struct {
+	verifier repository.TokenVerifier
+}
+
+// NewAuthHandler returns the authentication middleware; a nil verifier
+// disables authentication.
+func NewAuthHandler(verifier repository.TokenVerifier) *AuthHandler {
+	return &AuthHandler{
+		verifier: verifier,
+	}
+}
+
+// Authenticate rejects requests without a valid bearer token and passes the
+// token's user on in the request context.
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	if h.verifier == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="airlance"`)
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := h.verifier.Verify(r.Context(), token)
+		if err != nil {
+			logrus.WithError(err).Debug("Rejected access token")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="airlance", error="invalid_token"`)
+			http.Error(w, "invalid access token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(entity.ContextWithUser(r.Context(), user)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
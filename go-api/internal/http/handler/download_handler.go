@@ -1,11 +1,13 @@
 package handler
 
 import (
-	"fmt"
-	"io"
+	"errors"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/repository"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -22,17 +24,77 @@ func NewDownloadHandler(downloadUseCase *usecase.DownloadUseCase) *DownloadHandl
 func (h *DownloadHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	jobUUID := chi.URLParam(r, "uuid")
+	filename := chi.URLParam(r, "filename")
 
-	result, err := h.downloadUseCase.Execute(ctx, jobUUID)
+	if redirect, _ := strconv.ParseBool(r.URL.Query().Get("redirect")); redirect {
+		url, err := h.downloadUseCase.Presign(ctx, jobUUID, filename)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	result, err := h.downloadUseCase.Execute(ctx, jobUUID, filename)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Filename}))
+	serveDownload(w, r, result)
+}
+
+// HandleStream serves playlists and segments inline so players can fetch them.
+func (h *DownloadHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobUUID := chi.URLParam(r, "uuid")
+	name := chi.URLParam(r, "*")
+
+	result, err := h.downloadUseCase.ExecuteStream(ctx, jobUUID, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	serveDownload(w, r, result)
+}
+
+// HandleSignedStream serves stream files to players, authorized by the
+// stream token in the URL rather than an access token.
+func (h *DownloadHandler) HandleSignedStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobUUID := chi.URLParam(r, "uuid")
+	token := chi.URLParam(r, "token")
+	name := chi.URLParam(r, "*")
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	result, err := h.downloadUseCase.ExecuteSignedStream(ctx, jobUUID, token, name)
+	if errors.Is(err, repository.ErrInvalidStreamToken) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+
+	serveDownload(w, r, result)
+}
+
+// serveDownload writes a stored object with support for byte ranges and
+// conditional requests (If-None-Match, If-Modified-Since, If-Range), keyed on
+// the object's ETag and modification time.
+func serveDownload(w http.ResponseWriter, r *http.Request, result *usecase.DownloadResult) {
 	defer result.Reader.Close()
 
 	w.Header().Set("Content-Type", result.ContentType)
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", result.Size))
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", result.Filename))
+	if result.ETag != "" {
+		w.Header().Set("ETag", `"`+result.ETag+`"`)
+	}
 
-	io.Copy(w, result.Reader)
+	http.ServeContent(w, r, result.Filename, result.LastModified, result.Reader)
 }
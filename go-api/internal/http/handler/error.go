@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/domain/service"
+)
+
+// writeError answers validation and quota errors with a structured 4xx
+// body, the use case sentinels with their plain 4xx and anything else with
+// a plain 500.
+func writeError(w http.ResponseWriter, err error) {
+	var quotaErr *usecase.QuotaError
+	if errors.As(err, &quotaErr) {
+		writeTooManyRequests(w, quotaErr)
+		return
+	}
+
+	var validationErr *service.ValidationError
+	if !errors.As(err, &validationErr) {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(validationStatus(validationErr.Code))
+	json.NewEncoder(w).Encode(dto.ErrorResponse{Error: dto.ErrorDetail{
+		Code:    validationErr.Code,
+		Field:   validationErr.Field,
+		Message: validationErr.Message,
+	}})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrJobNotUploading), errors.Is(err, usecase.ErrJobFinished):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidJobQuery):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func validationStatus(code string) int {
+	switch code {
+	case service.CodeInvalidRequest:
+		return http.StatusBadRequest
+	case service.CodeFileTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case service.CodeUnsupportedFormat, service.CodeFormatMismatch:
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// writeTooManyRequests answers 429 with the limit that was hit and, when
+// waiting helps, a Retry-After in whole seconds.
+func writeTooManyRequests(w http.ResponseWriter, err *usecase.QuotaError) {
+	if err.RetryAfter > 0 {
+		seconds := int64(math.Ceil(err.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(dto.ErrorResponse{Error: dto.ErrorDetail{
+		Code:    err.Code,
+		Message: err.Message,
+	}})
+}
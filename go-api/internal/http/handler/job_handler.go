@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+type JobHandler struct {
+	jobListUseCase      *usecase.JobListUseCase
+	jobLifecycleUseCase *usecase.JobLifecycleUseCase
+}
+
+func NewJobHandler(jobListUseCase *usecase.JobListUseCase, jobLifecycleUseCase *usecase.JobLifecycleUseCase) *JobHandler {
+	return &JobHandler{
+		jobListUseCase:      jobListUseCase,
+		jobLifecycleUseCase: jobLifecycleUseCase,
+	}
+}
+
+// List pages through jobs. status may be repeated or comma separated.
+func (h *JobHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	req := dto.JobListRequest{
+		MediaType:     query.Get("media_type"),
+		Owner:         query.Get("owner"),
+		CreatedAfter:  query.Get("created_after"),
+		CreatedBefore: query.Get("created_before"),
+		Sort:          query.Get("sort"),
+		Cursor:        query.Get("cursor"),
+	}
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				req.Statuses = append(req.Statuses, status)
+			}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+		req.Limit = limit
+	}
+
+	resp, err := h.jobListUseCase.List(r.Context(), req)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidJobQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, usecase.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Cancel stops a job that has not finished yet.
+func (h *JobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.jobLifecycleUseCase.Cancel(r.Context(), chi.URLParam(r, "uuid"))
+	switch {
+	case errors.Is(err, repository.ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, usecase.ErrJobFinished):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Delete removes a job and everything stored for it.
+func (h *JobHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	err := h.jobLifecycleUseCase.Delete(r.Context(), chi.URLParam(r, "uuid"))
+	switch {
+	case errors.Is(err, repository.ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
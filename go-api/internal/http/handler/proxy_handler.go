@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type ProxyHandler struct {
+	trusted []netip.Prefix
+}
+
+// NewProxyHandler returns the middleware resolving client addresses behind
+// the given reverse proxies; with none, forwarded headers are ignored.
+func NewProxyHandler(trusted []netip.Prefix) *ProxyHandler {
+	return &ProxyHandler{
+		trusted: trusted,
+	}
+}
+
+// RealIP sets RemoteAddr to the client named by X-Forwarded-For or
+// X-Real-IP, but only on requests from a trusted proxy: anyone else could
+// send the headers to pose as another address. The client is the rightmost
+// X-Forwarded-For entry that is not itself a trusted proxy, as the entries
+// left of it are whatever the client sent.
+func (h *ProxyHandler) RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.isTrusted(clientIP(r)) {
+			if ip := h.forwardedIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *ProxyHandler) forwardedIP(r *http.Request) string {
+	if header := r.Header.Values("X-Forwarded-For"); len(header) > 0 {
+		hops := strings.Split(strings.Join(header, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				return ""
+			}
+			if !h.isTrusted(hop) || i == 0 {
+				return hop
+			}
+		}
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		if _, err := netip.ParseAddr(ip); err == nil {
+			return ip
+		}
+	}
+	return ""
+}
+
+func (h *ProxyHandler) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range h.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the address of the client, as resolved by RealIP.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestProxyRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:4000", "", "", "203.0.113.7"},
+		{"spoofed by untrusted peer", "203.0.113.7:4000", "198.51.100.1", "198.51.100.2", "203.0.113.7"},
+		{"behind proxy", "10.0.0.2:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"forged hop left of client", "10.0.0.2:4000", "192.0.2.9, 198.51.100.1", "", "198.51.100.1"},
+		{"proxy chain", "10.0.0.2:4000", "198.51.100.1, 10.0.0.3", "", "198.51.100.1"},
+		{"x-real-ip from proxy", "10.0.0.2:4000", "", "198.51.100.1", "198.51.100.1"},
+		{"malformed hop", "10.0.0.2:4000", "bogus", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/upload", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			NewProxyHandler(trusted).RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("clientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/airlance/api/internal/application/usecase"
+)
+
+type QuotaHandler struct {
+	quotaUseCase *usecase.QuotaUseCase
+}
+
+func NewQuotaHandler(quotaUseCase *usecase.QuotaUseCase) *QuotaHandler {
+	return &QuotaHandler{
+		quotaUseCase: quotaUseCase,
+	}
+}
+
+func (h *QuotaHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.quotaUseCase.Usage(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
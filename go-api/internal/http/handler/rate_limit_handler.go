@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type RateLimitHandler struct {
+	perUser repository.RateLimiter
+	perIP   repository.RateLimiter
+}
+
+// NewRateLimitHandler returns the upload rate limiting middleware; a nil
+// limiter disables that limit.
+func NewRateLimitHandler(perUser, perIP repository.RateLimiter) *RateLimitHandler {
+	return &RateLimitHandler{
+		perUser: perUser,
+		perIP:   perIP,
+	}
+}
+
+// Limit answers 429 once the client IP or the authenticated user is over
+// its rate. Admin users are not limited.
+func (h *RateLimitHandler) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := entity.UserFromContext(r.Context())
+		if ok && user.Admin {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if h.perIP != nil {
+			ip := clientIP(r)
+			if retryAfter, allowed := h.perIP.Allow(ip); !allowed {
+				logrus.WithField("ip", ip).Debug("Upload rate limit hit")
+				writeTooManyRequests(w, &usecase.QuotaError{
+					Code:       usecase.CodeRateLimited,
+					Message:    "too many uploads from this address",
+					RetryAfter: retryAfter,
+				})
+				return
+			}
+		}
+
+		if h.perUser != nil && ok {
+			if retryAfter, allowed := h.perUser.Allow(user.ID); !allowed {
+				logrus.WithField("user", user.ID).Debug("Upload rate limit hit")
+				writeTooManyRequests(w, &usecase.QuotaError{
+					Code:       usecase.CodeRateLimited,
+					Message:    "too many uploads",
+					RetryAfter: retryAfter,
+				})
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/go-chi/chi/v5"
+)
+
+type SessionHandler struct {
+	sessionUseCase *usecase.UploadSessionUseCase
+}
+
+func NewSessionHandler(sessionUseCase *usecase.UploadSessionUseCase) *SessionHandler {
+	return &SessionHandler{
+		sessionUseCase: sessionUseCase,
+	}
+}
+
+// Create starts a direct upload and returns presigned URLs for the inputs.
+func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.UploadSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid upload session request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.sessionUseCase.Create(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Commit enqueues a job once its inputs are uploaded. The body lists the
+// parts of multipart uploads and may be empty otherwise.
+func (h *SessionHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobUUID := chi.URLParam(r, "uuid")
+
+	var req dto.CommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid commit request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.sessionUseCase.Commit(ctx, jobUUID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
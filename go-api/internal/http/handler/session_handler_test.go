@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/service"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSessionCreateRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    string
+		wantStatus int
+		wantCode   string
+		wantField  string
+	}{
+		{"preset", `"preset":"bogus"`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "preset"},
+		{"output mode", `"output":"dvd"`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "output"},
+		{"motion", `"motion":"spin"`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "motion"},
+		{"visualizer color", `"visualizer":"waveform","visualizer_color":"red"`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "visualizer_color"},
+		{"loudness target", `"loudnorm":true,"loudnorm_target":3`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "loudnorm_target"},
+		{"transport", `"transport":"ftp"`, http.StatusUnprocessableEntity, service.CodeInvalidOption, "transport"},
+		{"media format", `"preset":"h264-mp4"`, http.StatusUnsupportedMediaType, service.CodeUnsupportedFormat, "media"},
+	}
+
+	// Options are validated before the job is stored, so no repositories are needed.
+	sessionUseCase := usecase.NewUploadSessionUseCase(nil, nil, nil,
+		service.NewValidationService(service.UploadLimits{}), nil, logrus.New(), "", time.Hour)
+	h := NewSessionHandler(sessionUseCase)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media := "cover.jpg"
+			if tt.wantField == "media" {
+				media = "cover.txt"
+			}
+			body := `{"media":{"filename":"` + media + `","size":1},"audio":{"filename":"track.mp3","size":1},` + tt.options + `}`
+
+			rec := httptest.NewRecorder()
+			h.Create(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions", strings.NewReader(body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			var resp dto.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode error response: %v", err)
+			}
+			if resp.Error.Code != tt.wantCode || resp.Error.Field != tt.wantField {
+				t.Errorf("error = %+v, want code %s field %s", resp.Error, tt.wantCode, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/application/dto"
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/domain/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// statusChecksumMismatch is the tus checksum extension's status for a body
+// that does not match its Upload-Checksum.
+const statusChecksumMismatch = 460
+
+// TusHandler serves the tus 1.0 protocol with the creation, termination and
+// checksum extensions.
+type TusHandler struct {
+	tusUseCase   *usecase.TusUseCase
+	baseURL      string
+	chunkTimeout time.Duration
+}
+
+func NewTusHandler(tusUseCase *usecase.TusUseCase, baseURL string, chunkTimeout time.Duration) *TusHandler {
+	return &TusHandler{
+		tusUseCase:   tusUseCase,
+		baseURL:      baseURL,
+		chunkTimeout: chunkTimeout,
+	}
+}
+
+// Resumable sets Tus-Resumable on every response and rejects requests for
+// another protocol version. OPTIONS is exempt so clients can discover it.
+func (h *TusHandler) Resumable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", usecase.TusVersion)
+		if r.Method != http.MethodOptions && r.Header.Get("Tus-Resumable") != usecase.TusVersion {
+			w.Header().Set("Tus-Version", usecase.TusVersion)
+			http.Error(w, "unsupported tus version", http.StatusPreconditionFailed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Options advertises the server's tus capabilities.
+func (h *TusHandler) Options(w http.ResponseWriter, r *http.Request) {
+	algorithms := make([]string, 0, len(usecase.TusChecksumAlgorithms))
+	for name := range usecase.TusChecksumAlgorithms {
+		algorithms = append(algorithms, name)
+	}
+	sort.Strings(algorithms)
+
+	w.Header().Set("Tus-Version", usecase.TusVersion)
+	w.Header().Set("Tus-Extension", usecase.TusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(usecase.TusMaxSize, 10))
+	w.Header().Set("Tus-Checksum-Algorithm", strings.Join(algorithms, ","))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Create starts an upload of one session input.
+func (h *TusHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		http.Error(w, "deferred upload length is not supported", http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, "invalid Upload-Metadata", http.StatusBadRequest)
+		return
+	}
+
+	upload, err := h.tusUseCase.Create(r.Context(), length, metadata)
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", h.baseURL+"/files/"+upload.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Head reports the offset to resume an upload from.
+func (h *TusHandler) Head(w http.ResponseWriter, r *http.Request) {
+	upload, err := h.tusUseCase.Head(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeTusOffset(w, upload)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Patch appends the request body to an upload.
+func (h *TusHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	var checksum *dto.TusChecksum
+	if header := r.Header.Get("Upload-Checksum"); header != "" {
+		// A header without a digest is malformed, not a mismatch.
+		algorithm, value, found := strings.Cut(header, " ")
+		sum, err := base64.StdEncoding.DecodeString(value)
+		if !found || algorithm == "" || value == "" || err != nil {
+			http.Error(w, "invalid Upload-Checksum", http.StatusBadRequest)
+			return
+		}
+		checksum = &dto.TusChecksum{Algorithm: algorithm, Sum: sum}
+	}
+
+	// A chunk can take far longer than the server timeouts on a slow link.
+	deadline := time.Now().Add(h.chunkTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		logrus.WithError(err).Warn("Failed to extend tus read deadline")
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		logrus.WithError(err).Warn("Failed to extend tus write deadline")
+	}
+
+	upload, err := h.tusUseCase.Patch(r.Context(), chi.URLParam(r, "id"), offset, checksum, r.Body)
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete terminates an unfinished upload.
+func (h *TusHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.tusUseCase.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeTusError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTusOffset(w http.ResponseWriter, upload *dto.TusUpload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+}
+
+func writeTusError(w http.ResponseWriter, err error) {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecase.ErrUploadNotFound), errors.Is(err, repository.ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, usecase.ErrOffsetMismatch), errors.Is(err, usecase.ErrJobNotUploading):
+		status = http.StatusConflict
+	case errors.Is(err, usecase.ErrChecksumMismatch):
+		status = statusChecksumMismatch
+	case errors.Is(err, usecase.ErrUnsupportedChecksum), errors.Is(err, usecase.ErrMalformedChecksum):
+		status = http.StatusBadRequest
+	case errors.Is(err, usecase.ErrUploadTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma separated keys,
+// each followed by a space and its base64 encoded value, if any.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata, nil
+}
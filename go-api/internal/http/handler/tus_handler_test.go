@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airlance/api/internal/application/usecase"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTusPatchRejectsMalformedChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum string
+		want     int
+	}{
+		{"algorithm only", "sha1", http.StatusBadRequest},
+		{"empty digest", "sha1 ", http.StatusBadRequest},
+		{"digest only", " 2jmj7l5rSw0yVb/vlWAYkK/YBwk=", http.StatusBadRequest},
+		{"bad base64", "sha1 not-base64!", http.StatusBadRequest},
+		{"short digest", "sha1 AAAA", http.StatusBadRequest},
+		{"unsupported algorithm", "crc32 AAAAAA==", http.StatusBadRequest},
+	}
+
+	// Checksums are checked before the upload is looked up, so no
+	// repositories are needed.
+	tusUseCase := usecase.NewTusUseCase(nil, nil, nil, nil, logrus.New())
+	h := NewTusHandler(tusUseCase, "", time.Minute)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/files/upload", strings.NewReader("chunk"))
+			r.Header.Set("Content-Type", "application/offset+octet-stream")
+			r.Header.Set("Upload-Offset", "0")
+			r.Header.Set("Upload-Checksum", tt.checksum)
+
+			rec := httptest.NewRecorder()
+			h.Patch(rec, r)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
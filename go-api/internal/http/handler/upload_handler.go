@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/airlance/api/internal/application/dto"
 	"github.com/airlance/api/internal/application/usecase"
+	"github.com/airlance/api/internal/domain/entity"
 )
 
 type UploadHandler struct {
@@ -36,6 +40,36 @@ func (h *UploadHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 	defer audioFile.Close()
 
+	var subtitles *dto.UploadFile
+	subtitlesFile, subtitlesHeader, err := r.FormFile("subtitles")
+	switch {
+	case err == nil:
+		defer subtitlesFile.Close()
+		subtitles = &dto.UploadFile{
+			Filename:    subtitlesHeader.Filename,
+			Size:        subtitlesHeader.Size,
+			ContentType: subtitlesHeader.Header.Get("Content-Type"),
+			Reader:      subtitlesFile,
+		}
+	case !errors.Is(err, http.ErrMissingFile):
+		http.Error(w, "failed to read subtitles file", http.StatusBadRequest)
+		return
+	}
+
+	fontSize := 0
+	if value := r.FormValue("subtitles_font_size"); value != "" {
+		if fontSize, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "subtitles_font_size must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	loudnorm, err := formLoudnorm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	req := dto.UploadRequest{
 		MediaFilename:    mediaHeader.Filename,
 		MediaSize:        mediaHeader.Size,
@@ -43,14 +77,119 @@ func (h *UploadHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		AudioFilename:    audioHeader.Filename,
 		AudioSize:        audioHeader.Size,
 		AudioContentType: audioHeader.Header.Get("Content-Type"),
+		Preset:           r.FormValue("preset"),
+		OutputMode:       r.FormValue("output"),
+		Motion:           r.FormValue("motion"),
+		Visualizer: entity.Visualizer{
+			Style:    r.FormValue("visualizer"),
+			Color:    r.FormValue("visualizer_color"),
+			Position: r.FormValue("visualizer_position"),
+		},
+		Subtitles:         subtitles,
+		SubtitlesMode:     r.FormValue("subtitles_mode"),
+		SubtitlesFontSize: fontSize,
+		SubtitlesColor:    r.FormValue("subtitles_color"),
+		Loudnorm:          loudnorm,
 	}
 
 	resp, err := h.uploadUseCase.Execute(ctx, req, mediaFile, audioFile)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleTimeline accepts a timeline manifest (form field or file "manifest")
+// and its assets as repeated "assets" files.
+func (h *UploadHandler) HandleTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := r.ParseMultipartForm(100 << 20); err != nil {
+		http.Error(w, "multipart form required", http.StatusBadRequest)
+		return
+	}
+
+	manifest := []byte(r.FormValue("manifest"))
+	if len(manifest) == 0 {
+		manifestFile, _, err := r.FormFile("manifest")
+		if err != nil {
+			http.Error(w, "timeline manifest required", http.StatusBadRequest)
+			return
+		}
+		manifest, err = io.ReadAll(manifestFile)
+		manifestFile.Close()
+		if err != nil {
+			http.Error(w, "failed to read timeline manifest", http.StatusBadRequest)
+			return
+		}
+	}
+
+	headers := r.MultipartForm.File["assets"]
+	if len(headers) == 0 {
+		http.Error(w, "timeline assets required", http.StatusBadRequest)
+		return
+	}
+
+	loudnorm, err := formLoudnorm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := dto.TimelineUploadRequest{
+		Manifest:   manifest,
+		Preset:     r.FormValue("preset"),
+		OutputMode: r.FormValue("output"),
+		Loudnorm:   loudnorm,
+	}
+
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			http.Error(w, "failed to read asset "+header.Filename, http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		req.Assets = append(req.Assets, dto.UploadFile{
+			Filename:    header.Filename,
+			Size:        header.Size,
+			ContentType: header.Header.Get("Content-Type"),
+			Reader:      file,
+		})
+	}
+
+	resp, err := h.uploadUseCase.ExecuteTimeline(ctx, req)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+// formLoudnorm reads the loudnorm, loudnorm_target and loudnorm_true_peak
+// form fields.
+func formLoudnorm(r *http.Request) (entity.Loudnorm, error) {
+	var loudnorm entity.Loudnorm
+	var err error
+	if value := r.FormValue("loudnorm"); value != "" {
+		if loudnorm.Enabled, err = strconv.ParseBool(value); err != nil {
+			return loudnorm, errors.New("loudnorm must be true or false")
+		}
+	}
+	if value := r.FormValue("loudnorm_target"); value != "" {
+		if loudnorm.Integrated, err = strconv.ParseFloat(value, 64); err != nil {
+			return loudnorm, errors.New("loudnorm_target must be a number")
+		}
+	}
+	if value := r.FormValue("loudnorm_true_peak"); value != "" {
+		if loudnorm.TruePeak, err = strconv.ParseFloat(value, 64); err != nil {
+			return loudnorm, errors.New("loudnorm_true_peak must be a number")
+		}
+	}
+	return loudnorm, nil
+}
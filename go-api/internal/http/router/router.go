@@ -10,20 +10,44 @@ import (
 )
 
 type Router struct {
-	uploadHandler   *handler2.UploadHandler
-	statusHandler   *handler2.StatusHandler
-	downloadHandler *handler2.DownloadHandler
+	uploadHandler    *handler2.UploadHandler
+	statusHandler    *handler2.StatusHandler
+	downloadHandler  *handler2.DownloadHandler
+	artifactHandler  *handler2.ArtifactHandler
+	sessionHandler   *handler2.SessionHandler
+	tusHandler       *handler2.TusHandler
+	authHandler      *handler2.AuthHandler
+	rateLimitHandler *handler2.RateLimitHandler
+	quotaHandler     *handler2.QuotaHandler
+	jobHandler       *handler2.JobHandler
+	proxyHandler     *handler2.ProxyHandler
 }
 
 func NewRouter(
 	uploadHandler *handler2.UploadHandler,
 	statusHandler *handler2.StatusHandler,
 	downloadHandler *handler2.DownloadHandler,
+	artifactHandler *handler2.ArtifactHandler,
+	sessionHandler *handler2.SessionHandler,
+	tusHandler *handler2.TusHandler,
+	authHandler *handler2.AuthHandler,
+	rateLimitHandler *handler2.RateLimitHandler,
+	quotaHandler *handler2.QuotaHandler,
+	jobHandler *handler2.JobHandler,
+	proxyHandler *handler2.ProxyHandler,
 ) *Router {
 	return &Router{
-		uploadHandler:   uploadHandler,
-		statusHandler:   statusHandler,
-		downloadHandler: downloadHandler,
+		uploadHandler:    uploadHandler,
+		statusHandler:    statusHandler,
+		downloadHandler:  downloadHandler,
+		artifactHandler:  artifactHandler,
+		sessionHandler:   sessionHandler,
+		tusHandler:       tusHandler,
+		authHandler:      authHandler,
+		rateLimitHandler: rateLimitHandler,
+		quotaHandler:     quotaHandler,
+		jobHandler:       jobHandler,
+		proxyHandler:     proxyHandler,
 	}
 }
 
@@ -32,13 +56,46 @@ func (rt *Router) Setup() http.Handler {
 
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.RealIP)
+	r.Use(rt.proxyHandler.RealIP)
 	r.Use(middleware.RequestID)
+	r.Use(middleware.GetHead)
 
 	r.Get("/", rt.healthCheck)
-	r.Post("/upload", rt.uploadHandler.Handle)
-	r.Get("/status/{uuid}", rt.statusHandler.Handle)
-	r.Get("/download/{uuid}/output.mp4", rt.downloadHandler.Handle)
+	r.Get("/stream/{uuid}/{token}/*", rt.downloadHandler.HandleSignedStream)
+
+	r.Group(func(r chi.Router) {
+		r.Use(rt.authHandler.Authenticate)
+
+		r.Group(func(r chi.Router) {
+			r.Use(rt.rateLimitHandler.Limit)
+			r.Post("/upload", rt.uploadHandler.Handle)
+			r.Post("/upload/timeline", rt.uploadHandler.HandleTimeline)
+			r.Post("/upload/session", rt.sessionHandler.Create)
+		})
+		r.Get("/jobs", rt.jobHandler.List)
+		r.Delete("/jobs/{uuid}", rt.jobHandler.Delete)
+		r.Post("/jobs/{uuid}/cancel", rt.jobHandler.Cancel)
+		r.Post("/jobs/{uuid}/commit", rt.sessionHandler.Commit)
+		r.Get("/status/{uuid}", rt.statusHandler.Handle)
+		r.Get("/download/{uuid}/{filename}", rt.downloadHandler.Handle)
+		r.Get("/download/{uuid}/hls/*", rt.downloadHandler.HandleStream)
+		r.Get("/jobs/{uuid}/artifacts", rt.artifactHandler.List)
+		r.Get("/jobs/{uuid}/artifacts/*", rt.artifactHandler.Download)
+		r.Get("/quota", rt.quotaHandler.Usage)
+	})
+
+	r.Route("/files", func(r chi.Router) {
+		r.Use(rt.tusHandler.Resumable)
+		r.Options("/", rt.tusHandler.Options)
+
+		r.Group(func(r chi.Router) {
+			r.Use(rt.authHandler.Authenticate)
+			r.Post("/", rt.tusHandler.Create)
+			r.Head("/{id}", rt.tusHandler.Head)
+			r.Patch("/{id}", rt.tusHandler.Patch)
+			r.Delete("/{id}", rt.tusHandler.Delete)
+		})
+	})
 
 	return r
 }
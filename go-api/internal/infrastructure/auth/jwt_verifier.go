@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/infrastructure/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// jwksRefreshInterval limits how often an unknown key ID triggers a JWKS
+// fetch, so forged key IDs cannot hammer the auth service.
+const jwksRefreshInterval = time.Minute
+
+// AccessTokenClaims are the claims of a supabase-auth access token that the
+// API relies on.
+type AccessTokenClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// JWTVerifier verifies access tokens issued by supabase-auth, signed either
+// with the shared GOTRUE_JWT_SECRET (HS256) or with one of the asymmetric
+// keys it publishes as a JWKS.
+type JWTVerifier struct {
+	secret     []byte
+	jwksURL    string
+	audience   string
+	issuer     string
+	adminRoles []string
+	client     *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+func NewJWTVerifier(cfg config.AuthConfig) repository.TokenVerifier {
+	return &JWTVerifier{
+		secret:     []byte(cfg.JWTSecret),
+		jwksURL:    cfg.JWKSURL,
+		audience:   cfg.Audience,
+		issuer:     cfg.Issuer,
+		adminRoles: cfg.AdminRoles,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*entity.User, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "RS512", "ES256", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+
+	var claims AccessTokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return v.key(ctx, t)
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", repository.ErrInvalidToken, err)
+	}
+
+	user := &entity.User{
+		ID:    claims.Subject,
+		Email: claims.Email,
+		Role:  claims.Role,
+		Admin: slices.Contains(v.adminRoles, claims.Role),
+	}
+	// Service role keys carry neither a subject nor an audience; user
+	// tokens need both, which also turns away anon keys.
+	if user.Admin {
+		return user, nil
+	}
+	if user.ID == "" {
+		return nil, fmt.Errorf("%w: token has no subject", repository.ErrInvalidToken)
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return nil, fmt.Errorf("%w: token is not for audience %s", repository.ErrInvalidToken, v.audience)
+	}
+	return user, nil
+}
+
+// key picks the verification key for a token: the shared secret for HS256,
+// otherwise the JWKS key named by its kid.
+func (v *JWTVerifier) key(ctx context.Context, t *jwt.Token) (any, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(v.secret) == 0 {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		return v.secret, nil
+	}
+
+	if v.jwksURL == "" {
+		return nil, fmt.Errorf("%s tokens are not accepted", t.Method.Alg())
+	}
+	kid, _ := t.Header["kid"].(string)
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > jwksRefreshInterval
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// refresh reloads the JWKS, picking up rotated keys.
+func (v *JWTVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another request may have refreshed while this one waited.
+	if time.Since(v.fetchedAt) <= jwksRefreshInterval {
+		return nil
+	}
+	v.fetchedAt = time.Now()
+
+	keys, err := fetchJWKS(ctx, v.client, v.jwksURL)
+	if err != nil {
+		logrus.WithError(err).WithField("url", v.jwksURL).Warn("Failed to fetch JWKS")
+		return err
+	}
+	v.keys = keys
+
+	logrus.WithField("keys", len(keys)).Debug("JWKS loaded")
+	return nil
+}
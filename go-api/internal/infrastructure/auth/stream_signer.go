@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+// HMACStreamSigner signs stream tokens with HMAC-SHA256. A token is the
+// expiry as a Unix time and the signature over it and the job UUID, so it
+// fits in a URL path segment.
+type HMACStreamSigner struct {
+	key []byte
+}
+
+func NewHMACStreamSigner(key []byte) repository.StreamSigner {
+	return &HMACStreamSigner{key: key}
+}
+
+func (s *HMACStreamSigner) Sign(jobUUID string, expires time.Time) string {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	return unix + "." + base64.RawURLEncoding.EncodeToString(s.mac(jobUUID, unix))
+}
+
+func (s *HMACStreamSigner) Verify(jobUUID, token string, now time.Time) error {
+	unix, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return repository.ErrInvalidStreamToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(jobUUID, unix)) {
+		return repository.ErrInvalidStreamToken
+	}
+	expires, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return repository.ErrInvalidStreamToken
+	}
+	return nil
+}
+
+func (s *HMACStreamSigner) mac(jobUUID, unix string) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(jobUUID + "\n" + unix))
+	return h.Sum(nil)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultOverTime = time.Hour
+
+// Rate is a rate limit written the way supabase-auth's conf.Rate reads it:
+// "events/duration" (e.g. "30/1h" or "5/10s"), or a bare number of events
+// per hour. Zero events disables the limit.
+type Rate struct {
+	Events   float64
+	OverTime time.Duration
+}
+
+// Decode parses a rate from its env-config string.
+func (r *Rate) Decode(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		r.Events = f
+		r.OverTime = defaultOverTime
+		return nil
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("rate: value does not match rate syntax %q", value)
+	}
+
+	// 52 because the uint needs to fit in a float64
+	e, err := strconv.ParseUint(parts[0], 10, 52)
+	if err != nil {
+		return fmt.Errorf("rate: events part of rate value %q failed to parse as uint64: %w", value, err)
+	}
+
+	d, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return fmt.Errorf("rate: over-time part of rate value %q failed to parse as duration: %w", value, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("rate: over-time part of rate value %q must be positive", value)
+	}
+
+	r.Events = float64(e)
+	r.OverTime = d
+	return nil
+}
+
+// Enabled reports whether the rate limits anything.
+func (r Rate) Enabled() bool {
+	return r.Events > 0 && r.OverTime > 0
+}
+
+func (r Rate) String() string {
+	if r.OverTime == 0 {
+		return fmt.Sprintf("%f", r.Events)
+	}
+	return fmt.Sprintf("%d/%s", uint64(r.Events), r.OverTime.String())
+}
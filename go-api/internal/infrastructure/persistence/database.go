@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DriverMemory   = "memory"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// OpenDatabase connects to the SQL job store and applies pending migrations.
+func OpenDatabase(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	var driverName string
+	switch driver {
+	case DriverPostgres:
+		driverName = "pgx"
+	case DriverSQLite:
+		driverName = "sqlite"
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if driver == DriverSQLite {
+		// SQLite serializes writers; a single connection avoids SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := migrate(ctx, db, driver); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB, driver string) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMP    NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+		var applied int
+		err := db.QueryRowContext(ctx,
+			rebind(driver, "SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), version,
+		).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		content, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+
+		if err := applyMigration(ctx, db, driver, version, string(content)); err != nil {
+			return err
+		}
+
+		logrus.WithField("version", version).Info("Database migration applied")
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, driver, version, content string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	// Each file runs as one Exec, so semicolons inside literals, comments or
+	// function bodies are left to the database to parse. Without arguments
+	// both drivers accept several statements at once: pgx sends them over
+	// the simple protocol and SQLite steps through them in turn.
+	if _, err := tx.ExecContext(ctx, content); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", version, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		rebind(driver, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"),
+		version, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
+}
+
+// rebind rewrites ? placeholders into the positional form the driver expects.
+func rebind(driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
@@ -2,7 +2,8 @@ package persistence
 
 import (
 	"context"
-	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -39,23 +40,186 @@ func (r *MemoryJobRepository) GetByUUID(ctx context.Context, uuid string) (*enti
 
 	job, exists := r.jobs[uuid]
 	if !exists {
-		return nil, fmt.Errorf("job not found")
+		return nil, repository.ErrJobNotFound
 	}
 
-	return job, nil
+	copied := *job
+	return &copied, nil
 }
 
-func (r *MemoryJobRepository) UpdateStatus(ctx context.Context, uuid string, status entity.JobStatus) error {
+func (r *MemoryJobRepository) List(ctx context.Context, filter entity.JobFilter) ([]*entity.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []*entity.Job
+	for _, job := range r.jobs {
+		if matchesFilter(job, filter) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+
+	slices.SortFunc(jobs, func(a, b *entity.Job) int {
+		c := compareCursors(a.Cursor(filter.Sort), b.Cursor(filter.Sort))
+		if filter.Descending {
+			return -c
+		}
+		return c
+	})
+
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+func matchesFilter(job *entity.Job, filter entity.JobFilter) bool {
+	if job.DeletedAt != nil {
+		return false
+	}
+	if filter.Owner != "" && job.Owner != filter.Owner {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, job.Status) {
+		return false
+	}
+	if filter.MediaType != nil && (job.MediaInfo == nil || job.MediaInfo.Type != *filter.MediaType) {
+		return false
+	}
+	if !filter.CreatedAfter.IsZero() && job.CreatedAt.Before(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !job.CreatedAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if !filter.CompletedBefore.IsZero() && (job.CompletedAt == nil || !job.CompletedAt.Before(filter.CompletedBefore)) {
+		return false
+	}
+	if filter.InputsKept && job.InputsDeletedAt != nil {
+		return false
+	}
+	if filter.After != nil {
+		c := compareCursors(job.Cursor(filter.Sort), *filter.After)
+		if (filter.Descending && c >= 0) || (!filter.Descending && c <= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareCursors(a, b entity.JobCursor) int {
+	if c := a.Time.Compare(b.Time); c != 0 {
+		return c
+	}
+	return strings.Compare(a.UUID, b.UUID)
+}
+
+func (r *MemoryJobRepository) UpdateStatus(ctx context.Context, uuid string, update entity.JobStatusUpdate) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	job, exists := r.jobs[uuid]
 	if !exists {
-		return fmt.Errorf("job not found")
+		return repository.ErrJobNotFound
 	}
 
-	job.Status = status
-	job.UpdatedAt = time.Now()
+	applyStatusUpdate(job, update)
+	return nil
+}
+
+func (r *MemoryJobRepository) TransitionStatus(ctx context.Context, uuid string, from []entity.JobStatus, update entity.JobStatusUpdate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.jobs[uuid]
+	if !exists {
+		return false, repository.ErrJobNotFound
+	}
+	if job.DeletedAt != nil || !slices.Contains(from, job.Status) {
+		return false, nil
+	}
+
+	applyStatusUpdate(job, update)
+	return true, nil
+}
+
+func applyStatusUpdate(job *entity.Job, update entity.JobStatusUpdate) {
+	now := time.Now()
+	job.Status = update.Status
+	job.ErrorMessage = update.ErrorMessage
+	job.FailureCode = update.FailureCode
+	job.Progress = update.Progress
+	if update.WorkerID != "" {
+		job.WorkerID = update.WorkerID
+	}
+	if update.Result != nil {
+		job.Result = update.Result
+	}
+	if update.MediaInfo != nil {
+		job.MediaInfo = update.MediaInfo
+	}
+	if update.StorageBytes != nil {
+		job.StorageBytes = *update.StorageBytes
+	}
+	if update.Status == entity.JobStatusProcessing && job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	if update.Status.IsTerminal() {
+		job.CompletedAt = &now
+	}
+	job.UpdatedAt = now
+}
+
+func (r *MemoryJobRepository) MarkDeleted(ctx context.Context, uuid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.jobs[uuid]
+	if !exists {
+		return repository.ErrJobNotFound
+	}
+
+	now := time.Now()
+	job.StorageBytes = 0
+	if job.DeletedAt == nil {
+		job.DeletedAt = &now
+	}
+	job.UpdatedAt = now
+
+	return nil
+}
+
+func (r *MemoryJobRepository) MarkInputsDeleted(ctx context.Context, uuid string, storageBytes int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.jobs[uuid]
+	if !exists {
+		return repository.ErrJobNotFound
+	}
+
+	now := time.Now()
+	job.StorageBytes = storageBytes
+	if job.InputsDeletedAt == nil {
+		job.InputsDeletedAt = &now
+	}
+	job.UpdatedAt = now
 
 	return nil
 }
+
+func (r *MemoryJobRepository) Usage(ctx context.Context, owner string, since time.Time) (*entity.Usage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	usage := &entity.Usage{}
+	for _, job := range r.jobs {
+		if job.Owner != owner {
+			continue
+		}
+		usage.StorageBytes += job.StorageBytes
+		usage.ProcessingTime += job.ProcessingTime(since, now)
+	}
+	return usage, nil
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    uuid          VARCHAR(36)  PRIMARY KEY,
+    media_path    TEXT         NOT NULL,
+    audio_path    TEXT         NOT NULL,
+    status        VARCHAR(32)  NOT NULL,
+    error_message TEXT         NOT NULL DEFAULT '',
+    worker_id     VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMP    NOT NULL,
+    updated_at    TIMESTAMP    NOT NULL,
+    started_at    TIMESTAMP    NULL,
+    completed_at  TIMESTAMP    NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
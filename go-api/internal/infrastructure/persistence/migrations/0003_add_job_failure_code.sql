@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN failure_code VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN preset VARCHAR(64) NOT NULL DEFAULT 'h264-mp4';
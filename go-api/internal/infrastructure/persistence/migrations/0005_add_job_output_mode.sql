@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN output_mode VARCHAR(16) NOT NULL DEFAULT 'file';
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN job_type VARCHAR(16) NOT NULL DEFAULT 'compose';
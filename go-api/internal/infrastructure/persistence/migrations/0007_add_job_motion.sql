@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN motion VARCHAR(16) NOT NULL DEFAULT 'none';
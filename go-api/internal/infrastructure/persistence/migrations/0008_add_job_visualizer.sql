@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN visualizer_style VARCHAR(16) NOT NULL DEFAULT 'none';
+ALTER TABLE jobs ADD COLUMN visualizer_color VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN visualizer_position VARCHAR(16) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN subtitles_path TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN subtitles_mode VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN subtitles_font_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN subtitles_color VARCHAR(16) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN loudnorm BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE jobs ADD COLUMN loudnorm_integrated DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN loudnorm_true_peak DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN result TEXT NOT NULL DEFAULT '';
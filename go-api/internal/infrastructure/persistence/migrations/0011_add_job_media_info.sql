@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN media_info TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN asset_info TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN owner VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_jobs_owner ON jobs (owner, created_at);
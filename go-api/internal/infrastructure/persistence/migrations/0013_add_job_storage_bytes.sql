@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN storage_bytes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN media_type VARCHAR(16) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at, uuid);
+CREATE INDEX IF NOT EXISTS idx_jobs_updated_at ON jobs (updated_at, uuid);
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN deleted_at TIMESTAMP NULL;
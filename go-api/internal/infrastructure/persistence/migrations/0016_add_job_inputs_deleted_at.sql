@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN inputs_deleted_at TIMESTAMP NULL;
@@ -0,0 +1,530 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+type SQLJobRepository struct {
+	db     *sql.DB
+	driver string
+}
+
+func NewSQLJobRepository(db *sql.DB, driver string) repository.JobRepository {
+	return &SQLJobRepository{
+		db:     db,
+		driver: driver,
+	}
+}
+
+const jobColumns = `uuid, job_type, owner, media_path, audio_path, preset, output_mode, motion,
+	visualizer_style, visualizer_color, visualizer_position,
+	subtitles_path, subtitles_mode, subtitles_font_size, subtitles_color,
+	loudnorm, loudnorm_integrated, loudnorm_true_peak,
+	media_info, asset_info, storage_bytes,
+	status, error_message, failure_code, worker_id, progress, result,
+	created_at, updated_at, started_at, completed_at, deleted_at, inputs_deleted_at`
+
+func (r *SQLJobRepository) Create(ctx context.Context, job *entity.Job) error {
+	now := time.Now().UTC()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	result, err := marshalResult(job.Result)
+	if err != nil {
+		return err
+	}
+	mediaInfo, err := marshalMediaInfo(job.MediaInfo)
+	if err != nil {
+		return err
+	}
+	assetInfo, err := marshalMediaInfo(job.AssetInfo)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, r.rebind(`INSERT INTO jobs (`+jobColumns+`, media_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		job.UUID,
+		string(job.Type),
+		job.Owner,
+		job.MediaPath,
+		job.AudioPath,
+		job.Preset,
+		job.OutputMode,
+		job.Motion,
+		job.Visualizer.Style,
+		job.Visualizer.Color,
+		job.Visualizer.Position,
+		job.Subtitles.Path,
+		job.Subtitles.Mode,
+		job.Subtitles.FontSize,
+		job.Subtitles.Color,
+		job.Loudnorm.Enabled,
+		job.Loudnorm.Integrated,
+		job.Loudnorm.TruePeak,
+		mediaInfo,
+		assetInfo,
+		job.StorageBytes,
+		string(job.Status),
+		job.ErrorMessage,
+		job.FailureCode,
+		job.WorkerID,
+		job.Progress,
+		result,
+		job.CreatedAt,
+		job.UpdatedAt,
+		nullTime(job.StartedAt),
+		nullTime(job.CompletedAt),
+		nullTime(job.DeletedAt),
+		nullTime(job.InputsDeletedAt),
+		mediaType(job.MediaInfo),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLJobRepository) GetByUUID(ctx context.Context, uuid string) (*entity.Job, error) {
+	row := r.db.QueryRowContext(ctx, r.rebind(`SELECT `+jobColumns+` FROM jobs WHERE uuid = ?`), uuid)
+
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *SQLJobRepository) List(ctx context.Context, filter entity.JobFilter) ([]*entity.Job, error) {
+	var (
+		where = []string{"deleted_at IS NULL"}
+		args  []any
+	)
+	if filter.Owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, filter.Owner)
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		where = append(where, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.MediaType != nil {
+		where = append(where, "media_type = ?")
+		args = append(args, filter.MediaType.String())
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.CreatedAfter.UTC())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.CreatedBefore.UTC())
+	}
+	if !filter.CompletedBefore.IsZero() {
+		where = append(where, "completed_at < ?")
+		args = append(args, filter.CompletedBefore.UTC())
+	}
+	if filter.InputsKept {
+		where = append(where, "inputs_deleted_at IS NULL")
+	}
+
+	column, op, order := "created_at", ">", "ASC"
+	if filter.Sort == entity.JobSortUpdatedAt {
+		column = "updated_at"
+	}
+	if filter.Descending {
+		op, order = "<", "DESC"
+	}
+	if filter.After != nil {
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND uuid %[2]s ?))", column, op))
+		args = append(args, filter.After.Time.UTC(), filter.After.Time.UTC(), filter.After.UUID)
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE ` + strings.Join(where, " AND ")
+	query += fmt.Sprintf(` ORDER BY %[1]s %[2]s, uuid %[2]s`, column, order)
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*entity.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *SQLJobRepository) UpdateStatus(ctx context.Context, uuid string, update entity.JobStatusUpdate) error {
+	affected, err := r.updateStatus(ctx, uuid, update, "")
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLJobRepository) TransitionStatus(ctx context.Context, uuid string, from []entity.JobStatus, update entity.JobStatusUpdate) (bool, error) {
+	placeholders := make([]string, len(from))
+	args := make([]any, len(from))
+	for i, status := range from {
+		placeholders[i] = "?"
+		args[i] = string(status)
+	}
+	condition := " AND deleted_at IS NULL AND status IN (" + strings.Join(placeholders, ", ") + ")"
+
+	affected, err := r.updateStatus(ctx, uuid, update, condition, args...)
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	// Nothing changed: either the job is in another state or it is unknown.
+	if _, err := r.GetByUUID(ctx, uuid); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// updateStatus applies the update to the job if it also meets condition, a
+// SQL fragment appended to the WHERE clause, and returns the rows changed.
+func (r *SQLJobRepository) updateStatus(ctx context.Context, uuid string, update entity.JobStatusUpdate, condition string, conditionArgs ...any) (int64, error) {
+	now := time.Now().UTC()
+
+	var completedAt sql.NullTime
+	if update.Status.IsTerminal() {
+		completedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	var startedAt sql.NullTime
+	if update.Status == entity.JobStatusProcessing {
+		startedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	result, err := marshalResult(update.Result)
+	if err != nil {
+		return 0, err
+	}
+	mediaInfo, err := marshalMediaInfo(update.MediaInfo)
+	if err != nil {
+		return 0, err
+	}
+
+	var storageBytes sql.NullInt64
+	if update.StorageBytes != nil {
+		storageBytes = sql.NullInt64{Int64: *update.StorageBytes, Valid: true}
+	}
+
+	args := []any{
+		string(update.Status),
+		update.ErrorMessage,
+		update.FailureCode,
+		update.Progress,
+		update.WorkerID,
+		update.WorkerID,
+		result,
+		result,
+		mediaInfo,
+		mediaInfo,
+		mediaType(update.MediaInfo),
+		mediaType(update.MediaInfo),
+		storageBytes,
+		startedAt,
+		completedAt,
+		now,
+		uuid,
+	}
+	res, err := r.db.ExecContext(ctx, r.rebind(`UPDATE jobs SET
+		status = ?,
+		error_message = ?,
+		failure_code = ?,
+		progress = ?,
+		worker_id = CASE WHEN ? = '' THEN worker_id ELSE ? END,
+		result = CASE WHEN ? = '' THEN result ELSE ? END,
+		media_info = CASE WHEN ? = '' THEN media_info ELSE ? END,
+		media_type = CASE WHEN ? = '' THEN media_type ELSE ? END,
+		storage_bytes = COALESCE(?, storage_bytes),
+		started_at = COALESCE(started_at, ?),
+		completed_at = COALESCE(?, completed_at),
+		updated_at = ?
+		WHERE uuid = ?`+condition), append(args, conditionArgs...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update job status: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update job status: %w", err)
+	}
+	return affected, nil
+}
+
+func (r *SQLJobRepository) MarkDeleted(ctx context.Context, uuid string) error {
+	now := time.Now().UTC()
+
+	res, err := r.db.ExecContext(ctx, r.rebind(`UPDATE jobs SET
+		storage_bytes = 0,
+		deleted_at = COALESCE(deleted_at, ?),
+		updated_at = ?
+		WHERE uuid = ?`),
+		now,
+		now,
+		uuid,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark job deleted: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark job deleted: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLJobRepository) MarkInputsDeleted(ctx context.Context, uuid string, storageBytes int64) error {
+	now := time.Now().UTC()
+
+	res, err := r.db.ExecContext(ctx, r.rebind(`UPDATE jobs SET
+		storage_bytes = ?,
+		inputs_deleted_at = COALESCE(inputs_deleted_at, ?),
+		updated_at = ?
+		WHERE uuid = ?`),
+		storageBytes,
+		now,
+		now,
+		uuid,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark job inputs deleted: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark job inputs deleted: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLJobRepository) Usage(ctx context.Context, owner string, since time.Time) (*entity.Usage, error) {
+	usage := &entity.Usage{}
+
+	err := r.db.QueryRowContext(ctx, r.rebind(`SELECT COALESCE(SUM(storage_bytes), 0) FROM jobs WHERE owner = ?`), owner).
+		Scan(&usage.StorageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum job storage: %w", err)
+	}
+
+	// Processing time is summed here rather than in SQL, where date
+	// arithmetic differs between Postgres and SQLite.
+	rows, err := r.db.QueryContext(ctx, r.rebind(`SELECT started_at, completed_at FROM jobs
+		WHERE owner = ? AND started_at IS NOT NULL AND (completed_at IS NULL OR completed_at >= ?)`),
+		owner, since.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job processing times: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC()
+	for rows.Next() {
+		var (
+			job         entity.Job
+			startedAt   sql.NullTime
+			completedAt sql.NullTime
+		)
+		if err := rows.Scan(&startedAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to load job processing times: %w", err)
+		}
+		job.StartedAt = &startedAt.Time
+		if completedAt.Valid {
+			job.CompletedAt = &completedAt.Time
+		}
+		usage.ProcessingTime += job.ProcessingTime(since, now)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load job processing times: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (r *SQLJobRepository) rebind(query string) string {
+	return rebind(r.driver, query)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*entity.Job, error) {
+	var (
+		job         entity.Job
+		jobType     string
+		status      string
+		result      string
+		mediaInfo   string
+		assetInfo   string
+		startedAt   sql.NullTime
+		completedAt sql.NullTime
+		deletedAt   sql.NullTime
+		inputsAt    sql.NullTime
+	)
+
+	err := row.Scan(
+		&job.UUID,
+		&jobType,
+		&job.Owner,
+		&job.MediaPath,
+		&job.AudioPath,
+		&job.Preset,
+		&job.OutputMode,
+		&job.Motion,
+		&job.Visualizer.Style,
+		&job.Visualizer.Color,
+		&job.Visualizer.Position,
+		&job.Subtitles.Path,
+		&job.Subtitles.Mode,
+		&job.Subtitles.FontSize,
+		&job.Subtitles.Color,
+		&job.Loudnorm.Enabled,
+		&job.Loudnorm.Integrated,
+		&job.Loudnorm.TruePeak,
+		&mediaInfo,
+		&assetInfo,
+		&job.StorageBytes,
+		&status,
+		&job.ErrorMessage,
+		&job.FailureCode,
+		&job.WorkerID,
+		&job.Progress,
+		&result,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&startedAt,
+		&completedAt,
+		&deletedAt,
+		&inputsAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Type = entity.JobType(jobType)
+	job.Status = entity.JobStatus(status)
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if deletedAt.Valid {
+		job.DeletedAt = &deletedAt.Time
+	}
+	if inputsAt.Valid {
+		job.InputsDeletedAt = &inputsAt.Time
+	}
+	if result != "" {
+		job.Result = &entity.JobResult{}
+		if err := json.Unmarshal([]byte(result), job.Result); err != nil {
+			return nil, fmt.Errorf("failed to decode job result: %w", err)
+		}
+	}
+	if mediaInfo != "" {
+		if err := json.Unmarshal([]byte(mediaInfo), &job.MediaInfo); err != nil {
+			return nil, fmt.Errorf("failed to decode job media info: %w", err)
+		}
+	}
+	if assetInfo != "" {
+		if err := json.Unmarshal([]byte(assetInfo), &job.AssetInfo); err != nil {
+			return nil, fmt.Errorf("failed to decode job asset info: %w", err)
+		}
+	}
+
+	return &job, nil
+}
+
+// marshalResult encodes a job result for the result column; nil is stored
+// as an empty string.
+func marshalResult(result *entity.JobResult) (string, error) {
+	if result == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return string(data), nil
+}
+
+// marshalMediaInfo encodes upload probes for the media_info and asset_info
+// columns; nil and empty probes are stored as an empty string.
+func marshalMediaInfo(info any) (string, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode job media info: %w", err)
+	}
+	if string(data) == "null" || string(data) == "{}" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// mediaType is the value of the media_type column listings filter on.
+func mediaType(info *entity.MediaInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.Type.String()
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,294 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+)
+
+// newSQLiteRepository opens a migrated in-memory SQLite job store; the
+// single connection OpenDatabase keeps for SQLite holds the database.
+func newSQLiteRepository(t *testing.T) repository.JobRepository {
+	t.Helper()
+
+	db, err := OpenDatabase(context.Background(), DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewSQLJobRepository(db, DriverSQLite)
+}
+
+func newTestJob(uuid string) *entity.Job {
+	return &entity.Job{
+		UUID:      uuid,
+		Type:      entity.JobTypeCompose,
+		Owner:     "user-1",
+		MediaPath: uuid + "/media.jpg",
+		AudioPath: uuid + "/audio.mp3",
+		Preset:    entity.DefaultPreset,
+		Status:    entity.JobStatusPending,
+		Loudnorm:  entity.Loudnorm{Enabled: true, Integrated: -16, TruePeak: -1.5},
+		MediaInfo: &entity.MediaInfo{Format: entity.FormatJPEG, Type: entity.MediaTypeImage, Width: 640, Height: 480},
+	}
+}
+
+func TestSQLJobRepositoryCreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+
+	want := newTestJob("job-1")
+	if err := repo.Create(ctx, want); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.Create(ctx, newTestJob("job-1")); err == nil {
+		t.Error("Create() of a duplicate UUID succeeded")
+	}
+
+	got, err := repo.GetByUUID(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetByUUID() error = %v", err)
+	}
+	if got.UUID != want.UUID || got.Owner != want.Owner || got.MediaPath != want.MediaPath ||
+		got.Status != want.Status || got.Preset != want.Preset || got.Loudnorm != want.Loudnorm {
+		t.Errorf("GetByUUID() = %+v, want %+v", got, want)
+	}
+	if got.MediaInfo == nil || *got.MediaInfo != *want.MediaInfo {
+		t.Errorf("GetByUUID() media info = %+v, want %+v", got.MediaInfo, want.MediaInfo)
+	}
+
+	if _, err := repo.GetByUUID(ctx, "missing"); !errors.Is(err, repository.ErrJobNotFound) {
+		t.Errorf("GetByUUID() of unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestSQLJobRepositoryUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+	if err := repo.Create(ctx, newTestJob("job-1")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	storageBytes := int64(2048)
+	tests := []struct {
+		name   string
+		update entity.JobStatusUpdate
+		check  func(t *testing.T, job *entity.Job)
+	}{
+		{
+			name:   "processing",
+			update: entity.JobStatusUpdate{Status: entity.JobStatusProcessing, WorkerID: "worker-1", Progress: 40},
+			check: func(t *testing.T, job *entity.Job) {
+				if job.WorkerID != "worker-1" || job.Progress != 40 || job.StartedAt == nil || job.CompletedAt != nil {
+					t.Errorf("job = %+v, want started by worker-1 at 40%%", job)
+				}
+			},
+		},
+		{
+			name:   "worker id kept",
+			update: entity.JobStatusUpdate{Status: entity.JobStatusProcessing, Progress: 80},
+			check: func(t *testing.T, job *entity.Job) {
+				if job.WorkerID != "worker-1" || job.Progress != 80 {
+					t.Errorf("job = %+v, want worker-1 at 80%%", job)
+				}
+			},
+		},
+		{
+			name: "ready",
+			update: entity.JobStatusUpdate{
+				Status:       entity.JobStatusReady,
+				Progress:     100,
+				Result:       &entity.JobResult{Artifacts: []entity.Artifact{{Name: "poster.jpg"}}},
+				StorageBytes: &storageBytes,
+			},
+			check: func(t *testing.T, job *entity.Job) {
+				if job.CompletedAt == nil || job.StorageBytes != storageBytes {
+					t.Errorf("job = %+v, want completed with %d bytes", job, storageBytes)
+				}
+				if job.Result == nil || len(job.Result.Artifacts) != 1 || job.Result.Artifacts[0].Name != "poster.jpg" {
+					t.Errorf("job result = %+v, want the poster.jpg artifact", job.Result)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.UpdateStatus(ctx, "job-1", tt.update); err != nil {
+				t.Fatalf("UpdateStatus() error = %v", err)
+			}
+			job, err := repo.GetByUUID(ctx, "job-1")
+			if err != nil {
+				t.Fatalf("GetByUUID() error = %v", err)
+			}
+			if job.Status != tt.update.Status {
+				t.Errorf("status = %s, want %s", job.Status, tt.update.Status)
+			}
+			tt.check(t, job)
+		})
+	}
+
+	err := repo.UpdateStatus(ctx, "missing", entity.JobStatusUpdate{Status: entity.JobStatusFailed})
+	if !errors.Is(err, repository.ErrJobNotFound) {
+		t.Errorf("UpdateStatus() of unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestSQLJobRepositoryTransitionStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+	for _, uuid := range []string{"job-1", "job-2"} {
+		if err := repo.Create(ctx, newTestJob(uuid)); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := repo.MarkDeleted(ctx, "job-2"); err != nil {
+		t.Fatalf("MarkDeleted() error = %v", err)
+	}
+
+	live := []entity.JobStatus{entity.JobStatusPending, entity.JobStatusProcessing}
+	tests := []struct {
+		name    string
+		uuid    string
+		status  entity.JobStatus
+		want    bool
+		wantErr error
+	}{
+		{"pending to cancelled", "job-1", entity.JobStatusCancelled, true, nil},
+		{"cancelled is not overwritten", "job-1", entity.JobStatusReady, false, nil},
+		{"deleted is not updated", "job-2", entity.JobStatusReady, false, nil},
+		{"unknown job", "missing", entity.JobStatusReady, false, repository.ErrJobNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.TransitionStatus(ctx, tt.uuid, live, entity.JobStatusUpdate{Status: tt.status})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitionStatus() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TransitionStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	job, err := repo.GetByUUID(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetByUUID() error = %v", err)
+	}
+	if job.Status != entity.JobStatusCancelled {
+		t.Errorf("status = %s, want cancelled", job.Status)
+	}
+}
+
+func TestSQLJobRepositoryListPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+	for i := 1; i <= 5; i++ {
+		job := newTestJob(fmt.Sprintf("job-%d", i))
+		if i == 4 {
+			job.Owner = "user-2"
+		}
+		if err := repo.Create(ctx, job); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter entity.JobFilter
+		want   [][]string
+	}{
+		{
+			name:   "ascending",
+			filter: entity.JobFilter{Limit: 2},
+			want:   [][]string{{"job-1", "job-2"}, {"job-3", "job-4"}, {"job-5"}},
+		},
+		{
+			name:   "descending",
+			filter: entity.JobFilter{Descending: true, Limit: 2},
+			want:   [][]string{{"job-5", "job-4"}, {"job-3", "job-2"}, {"job-1"}},
+		},
+		{
+			name:   "owner",
+			filter: entity.JobFilter{Owner: "user-1", Limit: 3},
+			want:   [][]string{{"job-1", "job-2", "job-3"}, {"job-5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			for page, want := range tt.want {
+				jobs, err := repo.List(ctx, filter)
+				if err != nil {
+					t.Fatalf("List() page %d error = %v", page, err)
+				}
+				if got := jobUUIDs(jobs); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Fatalf("List() page %d = %v, want %v", page, got, want)
+				}
+				cursor := jobs[len(jobs)-1].Cursor(filter.Sort)
+				filter.After = &cursor
+			}
+
+			jobs, err := repo.List(ctx, filter)
+			if err != nil {
+				t.Fatalf("List() past the last page error = %v", err)
+			}
+			if len(jobs) != 0 {
+				t.Errorf("List() past the last page = %v, want none", jobUUIDs(jobs))
+			}
+		})
+	}
+}
+
+func TestSQLJobRepositoryMarkDeleted(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+	for _, uuid := range []string{"job-1", "job-2"} {
+		if err := repo.Create(ctx, newTestJob(uuid)); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	storageBytes := int64(4096)
+	if err := repo.UpdateStatus(ctx, "job-1", entity.JobStatusUpdate{Status: entity.JobStatusReady, StorageBytes: &storageBytes}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	if err := repo.MarkDeleted(ctx, "job-1"); err != nil {
+		t.Fatalf("MarkDeleted() error = %v", err)
+	}
+
+	job, err := repo.GetByUUID(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("GetByUUID() error = %v", err)
+	}
+	if job.DeletedAt == nil || job.StorageBytes != 0 {
+		t.Errorf("job = %+v, want deleted without storage", job)
+	}
+
+	jobs, err := repo.List(ctx, entity.JobFilter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := jobUUIDs(jobs); fmt.Sprint(got) != "[job-2]" {
+		t.Errorf("List() = %v, want [job-2]", got)
+	}
+
+	if err := repo.MarkDeleted(ctx, "missing"); !errors.Is(err, repository.ErrJobNotFound) {
+		t.Errorf("MarkDeleted() of unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func jobUUIDs(jobs []*entity.Job) []string {
+	uuids := make([]string, len(jobs))
+	for i, job := range jobs {
+		uuids[i] = job.UUID
+	}
+	return uuids
+}
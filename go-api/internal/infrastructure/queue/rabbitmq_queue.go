@@ -12,12 +12,13 @@ import (
 )
 
 type RabbitMQQueue struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
-	queueName string
+	conn           *amqp.Connection
+	channel        *amqp.Channel
+	queueName      string
+	cancelExchange string
 }
 
-func NewRabbitMQQueue(url, queueName string) (repository.QueueRepository, error) {
+func NewRabbitMQQueue(url, queueName, cancelExchange string) (repository.QueueRepository, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
@@ -43,21 +44,76 @@ func NewRabbitMQQueue(url, queueName string) (repository.QueueRepository, error)
 		return nil, fmt.Errorf("failed to declare queue: %w", err)
 	}
 
+	err = channel.ExchangeDeclare(
+		cancelExchange,
+		amqp.ExchangeFanout,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
 	logrus.WithField("queue", queueName).Info("RabbitMQ connected")
 
 	return &RabbitMQQueue{
-		conn:      conn,
-		channel:   channel,
-		queueName: queueName,
+		conn:           conn,
+		channel:        channel,
+		queueName:      queueName,
+		cancelExchange: cancelExchange,
 	}, nil
 }
 
 func (q *RabbitMQQueue) PublishJob(ctx context.Context, job *entity.Job) error {
-	message := map[string]string{
+	message := map[string]any{
 		"uuid":   job.UUID,
-		"media":  job.MediaPath,
-		"audio":  job.AudioPath,
+		"type":   string(job.Type),
 		"bucket": "uploads",
+		"preset": job.Preset,
+		"output": job.OutputMode,
+	}
+	if job.Type == entity.JobTypeTimeline {
+		message["timeline"] = job.TimelinePath()
+		if len(job.AssetInfo) > 0 {
+			message["asset_info"] = job.AssetInfo
+		}
+	} else {
+		message["media"] = job.MediaPath
+		message["audio"] = job.AudioPath
+		message["motion"] = job.Motion
+		if job.MediaInfo != nil {
+			message["media_info"] = job.MediaInfo
+		}
+		if job.Visualizer.Enabled() {
+			message["visualizer"] = map[string]string{
+				"style":    job.Visualizer.Style,
+				"color":    job.Visualizer.Color,
+				"position": job.Visualizer.Position,
+			}
+		}
+		if job.Subtitles.Enabled() {
+			message["subtitles"] = map[string]any{
+				"path":      job.Subtitles.Path,
+				"mode":      job.Subtitles.Mode,
+				"font_size": job.Subtitles.FontSize,
+				"color":     job.Subtitles.Color,
+			}
+		}
+	}
+	if job.Loudnorm.Enabled {
+		loudnorm := map[string]float64{}
+		if job.Loudnorm.Integrated != 0 {
+			loudnorm["integrated"] = job.Loudnorm.Integrated
+		}
+		if job.Loudnorm.TruePeak != 0 {
+			loudnorm["true_peak"] = job.Loudnorm.TruePeak
+		}
+		message["loudnorm"] = loudnorm
 	}
 
 	jobData, err := json.Marshal(message)
@@ -81,6 +137,29 @@ func (q *RabbitMQQueue) PublishJob(ctx context.Context, job *entity.Job) error {
 	return nil
 }
 
+// PublishCancel broadcasts a cancel request to every worker.
+func (q *RabbitMQQueue) PublishCancel(ctx context.Context, jobUUID string) error {
+	body, err := json.Marshal(map[string]string{"uuid": jobUUID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cancel: %w", err)
+	}
+
+	err = q.channel.PublishWithContext(ctx,
+		q.cancelExchange,
+		"",
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to publish cancel: %w", err)
+	}
+
+	return nil
+}
+
 func (q *RabbitMQQueue) Close() error {
 	if q.channel != nil {
 		q.channel.Close()
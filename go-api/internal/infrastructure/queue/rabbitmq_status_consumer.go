@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/airlance/api/internal/domain/entity"
+	"github.com/airlance/api/internal/domain/repository"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+// statusMessage mirrors the status event published by the go-av worker.
+type statusMessage struct {
+	UUID     string            `json:"uuid"`
+	Status   string            `json:"status"`
+	Error    string            `json:"error"`
+	Code     string            `json:"code"`
+	WorkerID string            `json:"worker_id"`
+	Progress float64           `json:"progress"`
+	Result   *entity.JobResult `json:"result"`
+}
+
+type RabbitMQStatusConsumer struct {
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	queueName string
+}
+
+func NewRabbitMQStatusConsumer(url, exchange, queueName string) (repository.StatusConsumer, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	consumer := &RabbitMQStatusConsumer{
+		conn:      conn,
+		channel:   channel,
+		queueName: queueName,
+	}
+
+	if err := consumer.declare(exchange); err != nil {
+		consumer.Close()
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"exchange": exchange,
+		"queue":    queueName,
+	}).Info("RabbitMQ status consumer connected")
+
+	return consumer, nil
+}
+
+func (c *RabbitMQStatusConsumer) declare(exchange string) error {
+	err := c.channel.ExchangeDeclare(
+		exchange,
+		amqp.ExchangeTopic,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	_, err = c.channel.QueueDeclare(
+		c.queueName,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	if err := c.channel.QueueBind(c.queueName, "#", exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	return nil
+}
+
+// Consume delivers status events to handler until ctx is cancelled.
+func (c *RabbitMQStatusConsumer) Consume(ctx context.Context, handler repository.StatusHandler) error {
+	msgs, err := c.channel.ConsumeWithContext(ctx,
+		c.queueName,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start consuming: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-msgs:
+			if !ok {
+				return fmt.Errorf("status delivery channel closed")
+			}
+			c.handle(ctx, msg, handler)
+		}
+	}
+}
+
+func (c *RabbitMQStatusConsumer) handle(ctx context.Context, msg amqp.Delivery, handler repository.StatusHandler) {
+	var event statusMessage
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		logrus.WithError(err).Error("Failed to unmarshal status event")
+		msg.Nack(false, false)
+		return
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"job_uuid": event.UUID,
+		"status":   event.Status,
+	})
+
+	update := entity.JobStatusUpdate{
+		Status:       entity.JobStatus(event.Status),
+		ErrorMessage: event.Error,
+		FailureCode:  event.Code,
+		WorkerID:     event.WorkerID,
+		Progress:     event.Progress,
+		Result:       event.Result,
+	}
+
+	err := handler(ctx, event.UUID, update)
+	switch {
+	case err == nil:
+		msg.Ack(false)
+	case errors.Is(err, repository.ErrJobNotFound):
+		log.Warn("Status event for unknown job dropped")
+		msg.Ack(false)
+	default:
+		log.WithError(err).Error("Failed to apply status event")
+		msg.Nack(false, true)
+	}
+}
+
+func (c *RabbitMQStatusConsumer) Close() error {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/infrastructure/config"
+)
+
+// MemoryRateLimiter is a token bucket per key, kept in process memory: each
+// key may burst up to Events events, refilled evenly over OverTime. Limits
+// are per API instance.
+type MemoryRateLimiter struct {
+	burst    float64
+	interval time.Duration // time to refill one event
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	idleAfter time.Duration
+	sweptAt   time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewMemoryRateLimiter(rate config.Rate) repository.RateLimiter {
+	return &MemoryRateLimiter{
+		burst:     rate.Events,
+		interval:  time.Duration(float64(rate.OverTime) / rate.Events),
+		buckets:   make(map[string]*bucket),
+		idleAfter: rate.OverTime,
+		sweptAt:   time.Now(),
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+float64(now.Sub(b.updatedAt))/float64(l.interval))
+	b.updatedAt = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) * float64(l.interval)), false
+}
+
+// sweep drops buckets that have been idle long enough to be full again, so
+// keys seen once do not pile up.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < l.idleAfter {
+		return
+	}
+	l.sweptAt = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updatedAt) >= l.idleAfter {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -2,32 +2,57 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/airlance/api/internal/domain/repository"
+	"github.com/airlance/api/internal/infrastructure/config"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 	"github.com/sirupsen/logrus"
 )
 
 type MinIOStorage struct {
 	client *minio.Client
-	bucket string
+	core   minio.Core
+	// presigner signs URLs for the public endpoint. Its region is fixed so
+	// signing never has to reach that endpoint from inside the cluster.
+	presigner *minio.Client
+	bucket    string
 }
 
-func NewMinIOStorage(endpoint, accessKey, secretKey, bucket string, useSSL bool) (repository.StorageRepository, error) {
-	client, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
-		Secure: useSSL,
+func NewMinIOStorage(cfg config.MinIOConfig) (repository.StorageRepository, error) {
+	creds := credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to init MinIO: %w", err)
 	}
 
+	presigner, err := minio.New(cfg.PublicEndpoint, &minio.Options{
+		Creds:  creds,
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init MinIO presigner: %w", err)
+	}
+
 	storage := &MinIOStorage{
-		client: client,
-		bucket: bucket,
+		client:    client,
+		core:      minio.Core{Client: client},
+		presigner: presigner,
+		bucket:    cfg.Bucket,
 	}
 
 	if err := storage.ensureBucket(context.Background()); err != nil {
@@ -62,26 +87,27 @@ func (s *MinIOStorage) Upload(ctx context.Context, reader io.Reader, objectName
 	return nil
 }
 
-func (s *MinIOStorage) Download(ctx context.Context, objectName string) (io.ReadCloser, int64, string, error) {
+// Download opens the object lazily: minio.Object issues a ranged GetObject
+// for the current offset on the first Read after each Seek.
+func (s *MinIOStorage) Download(ctx context.Context, objectName string) (*repository.Object, error) {
 	obj, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
 	if err != nil {
-		return nil, 0, "", fmt.Errorf("failed to get object: %w", err)
+		return nil, fmt.Errorf("failed to get object: %w", err)
 	}
 
 	stat, err := obj.Stat()
 	if err != nil {
 		obj.Close()
-		return nil, 0, "", fmt.Errorf("failed to stat object: %w", err)
+		return nil, fmt.Errorf("failed to stat object: %w", notFound(err))
 	}
 
-	return obj, stat.Size, stat.ContentType, nil
+	return &repository.Object{ReadSeekCloser: obj, Info: objectInfo(stat)}, nil
 }
 
 func (s *MinIOStorage) Exists(ctx context.Context, objectName string) (bool, error) {
 	_, err := s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
 	if err != nil {
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
+		if errors.Is(notFound(err), repository.ErrObjectNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -92,3 +118,192 @@ func (s *MinIOStorage) Exists(ctx context.Context, objectName string) (bool, err
 func (s *MinIOStorage) Delete(ctx context.Context, objectName string) error {
 	return s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
 }
+
+func (s *MinIOStorage) List(ctx context.Context, prefix string) ([]repository.ObjectInfo, error) {
+	var objects []repository.ObjectInfo
+
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithMetadata: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		objects = append(objects, objectInfo(obj))
+	}
+
+	return objects, nil
+}
+
+func (s *MinIOStorage) Stat(ctx context.Context, objectName string) (repository.ObjectInfo, error) {
+	stat, err := s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return repository.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", notFound(err))
+	}
+	return objectInfo(stat), nil
+}
+
+// notFound maps a missing-object error to repository.ErrObjectNotFound.
+func notFound(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return repository.ErrObjectNotFound
+	}
+	return err
+}
+
+func (s *MinIOStorage) PresignPut(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	u, err := s.presigner.PresignedPutObject(ctx, s.bucket, objectName, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStorage) PresignGet(ctx context.Context, objectName, filename string, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	u, err := s.presigner.PresignedGetObject(ctx, s.bucket, objectName, expiry, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStorage) PresignUploadPart(ctx context.Context, objectName, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+
+	u, err := s.presigner.Presign(ctx, "PUT", s.bucket, objectName, expiry, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign part upload: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStorage) CreateMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	uploadID, err := s.core.NewMultipartUpload(ctx, s.bucket, objectName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *MinIOStorage) UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64) (string, error) {
+	part, err := s.core.PutObjectPart(ctx, s.bucket, objectName, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part: %w", err)
+	}
+	return part.ETag, nil
+}
+
+func (s *MinIOStorage) ListParts(ctx context.Context, objectName, uploadID string) ([]repository.UploadedPart, error) {
+	var (
+		parts  []repository.UploadedPart
+		marker int
+	)
+	for {
+		result, err := s.core.ListObjectParts(ctx, s.bucket, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts: %w", err)
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, repository.UploadedPart{Number: part.PartNumber, ETag: part.ETag, Size: part.Size})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+func (s *MinIOStorage) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []repository.CompletedPart) error {
+	completed := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completed[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+	}
+
+	if _, err := s.core.CompleteMultipartUpload(ctx, s.bucket, objectName, uploadID, completed, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *MinIOStorage) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	if err := s.core.AbortMultipartUpload(ctx, s.bucket, objectName, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *MinIOStorage) SetLifecycle(ctx context.Context, rules []repository.LifecycleRule) error {
+	cfg, err := s.client.GetBucketLifecycle(ctx, s.bucket)
+	if minio.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+		cfg, err = lifecycle.NewConfiguration(), nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	}
+
+	replaced := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		replaced[rule.ID] = true
+	}
+	kept := cfg.Rules[:0]
+	for _, rule := range cfg.Rules {
+		if !replaced[rule.ID] {
+			kept = append(kept, rule)
+		}
+	}
+	cfg.Rules = kept
+
+	for _, rule := range rules {
+		cfg.Rules = append(cfg.Rules, lifecycle.Rule{
+			ID:         rule.ID,
+			Status:     "Enabled",
+			RuleFilter: lifecycle.Filter{Prefix: rule.Prefix},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(rule.Days)},
+			AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: lifecycle.ExpirationDays(rule.AbortUploadsDays),
+			},
+		})
+	}
+
+	if err := s.client.SetBucketLifecycle(ctx, s.bucket, cfg); err != nil {
+		return fmt.Errorf("failed to set bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+func objectInfo(obj minio.ObjectInfo) repository.ObjectInfo {
+	return repository.ObjectInfo{
+		Name:         obj.Key,
+		Size:         obj.Size,
+		ContentType:  obj.ContentType,
+		ETag:         obj.ETag,
+		Checksum:     checksum(obj),
+		LastModified: obj.LastModified,
+	}
+}
+
+// checksum prefers a stored SHA-256 or CRC32C. Failing that, the ETag of an
+// object uploaded in one part is its MD5; multipart ETags ("<hash>-<parts>")
+// are not a content hash and are reported as such.
+func checksum(obj minio.ObjectInfo) string {
+	switch {
+	case obj.ChecksumSHA256 != "":
+		return "sha256:" + obj.ChecksumSHA256
+	case obj.ChecksumCRC32C != "":
+		return "crc32c:" + obj.ChecksumCRC32C
+	case obj.ETag == "":
+		return ""
+	case strings.Contains(obj.ETag, "-"):
+		return "etag:" + obj.ETag
+	default:
+		return "md5:" + obj.ETag
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/resoul/avcompression/config"
+	"github.com/resoul/avcompression/services"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayLimit int
+	replayUUID  string
+)
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "Inspect and manage the dead-letter queue",
+}
+
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-inject dead-lettered jobs into the job queue",
+	Long:  `Move jobs from the dead-letter queue back to the job queue with a fresh retry budget.`,
+	Run:   runDLQReplay,
+}
+
+func init() {
+	dlqReplayCmd.Flags().IntVarP(&replayLimit, "limit", "n", 0, "Maximum number of jobs to replay (0 = all)")
+	dlqReplayCmd.Flags().StringVar(&replayUUID, "uuid", "", "Only replay the job with this UUID")
+	dlqCmd.AddCommand(dlqReplayCmd)
+}
+
+func runDLQReplay(cmd *cobra.Command, args []string) {
+	cfg := config.MustLoad()
+	setupLogger(cfg.App.LogLevel)
+
+	rabbitService, err := services.NewRabbitMQService(cfg.RabbitMQ, retryPolicy(cfg))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize RabbitMQ service")
+	}
+	defer rabbitService.Close()
+
+	replayed, err := rabbitService.ReplayDeadLetters(context.Background(), replayLimit, replayUUID)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to replay dead-lettered jobs")
+	}
+
+	fmt.Printf("Replayed %d job(s) from %s\n", replayed, cfg.RabbitMQ.DeadLetterQueue)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "avcompression",
+	Short: "Airlance media processing worker",
+	Long:  `A worker that consumes jobs from RabbitMQ and renders videos from MinIO media with FFmpeg.`,
+	Run:   runWorker,
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+	rootCmd.AddCommand(dlqCmd)
+}
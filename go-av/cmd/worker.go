@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/resoul/avcompression/config"
+	"github.com/resoul/avcompression/services"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Start the processing worker",
+	Long:  `Consume jobs from RabbitMQ and process them until stopped.`,
+	Run:   runWorker,
+}
+
+func runWorker(cmd *cobra.Command, args []string) {
+	cfg := config.MustLoad()
+	setupLogger(cfg.App.LogLevel)
+	cfg.Print()
+
+	minioService, err := services.NewMinioService(cfg.Minio)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize MinIO service")
+	}
+
+	rabbitService, err := services.NewRabbitMQService(cfg.RabbitMQ, retryPolicy(cfg))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize RabbitMQ service")
+	}
+	defer rabbitService.Close()
+
+	cancels := services.NewCancelRegistry()
+	processor := services.NewProcessor(minioService, rabbitService, cancels, cfg.App)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := rabbitService.ConsumeCancels(ctx, cancels); err != nil {
+			logrus.WithError(err).Error("Cancel consumer stopped")
+		}
+	}()
+
+	logrus.WithField("concurrency", cfg.App.Concurrency).Info("Waiting for jobs...")
+	if err := rabbitService.Consume(ctx, processor, cfg.App.Concurrency, cfg.App.DrainTimeout); err != nil {
+		logrus.WithError(err).Fatal("Failed to consume messages")
+	}
+
+	logrus.Info("Worker stopped")
+}
+
+func retryPolicy(cfg *config.Config) services.RetryPolicy {
+	return services.RetryPolicy{
+		MaxRetries:    cfg.App.MaxRetries,
+		Backoff:       cfg.App.RetryBackoff,
+		RetryTimeouts: cfg.App.RetryTimeouts,
+	}
+}
+
+func setupLogger(level string) {
+	logrus.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp:   true,
+		TimestampFormat: "2006-01-02 15:04:05",
+		ForceColors:     true,
+	})
+
+	logrus.SetOutput(os.Stdout)
+
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		logrus.Warnf("Invalid log level '%s', defaulting to 'info'", level)
+		logLevel = logrus.InfoLevel
+	}
+	logrus.SetLevel(logLevel)
+}
@@ -1,8 +1,64 @@
 package models
 
+// Job types: a single media file over an audio track, or a timeline manifest.
+const (
+	JobTypeCompose  = "compose"
+	JobTypeTimeline = "timeline"
+)
+
 type JobMessage struct {
-	UUID      string `json:"uuid"`
-	MediaPath string `json:"media"`
-	AudioPath string `json:"audio"`
-	Bucket    string `json:"bucket"`
+	UUID         string      `json:"uuid"`
+	Type         string      `json:"type,omitempty"`
+	MediaPath    string      `json:"media,omitempty"`
+	AudioPath    string      `json:"audio,omitempty"`
+	TimelinePath string      `json:"timeline,omitempty"`
+	Bucket       string      `json:"bucket"`
+	Preset       string      `json:"preset,omitempty"`
+	Output       string      `json:"output,omitempty"`
+	Motion       string      `json:"motion,omitempty"`
+	Visualizer   *Visualizer `json:"visualizer,omitempty"`
+	Subtitles    *Subtitles  `json:"subtitles,omitempty"`
+	Loudnorm     *Loudnorm   `json:"loudnorm,omitempty"`
+	// MediaInfo and AssetInfo (by asset name) are the API's probes of the
+	// inputs at upload. They are missing for jobs enqueued by older APIs.
+	MediaInfo *MediaInfo            `json:"media_info,omitempty"`
+	AssetInfo map[string]*MediaInfo `json:"asset_info,omitempty"`
+}
+
+// MediaInfo describes an input as probed by the API from its container
+// headers. Type is "image", "video" or "audio"; Duration is in seconds and
+// zero when the container does not record it.
+type MediaInfo struct {
+	Format     string  `json:"format"`
+	Type       string  `json:"type"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	Duration   float64 `json:"duration,omitempty"`
+	VideoCodec string  `json:"video_codec,omitempty"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	HasAudio   bool    `json:"has_audio"`
+}
+
+// Loudnorm requests EBU R128 normalization of the audio track. Zero values
+// fall back to the worker's APP_LOUDNESS_TARGET and APP_TRUE_PEAK.
+type Loudnorm struct {
+	Integrated float64 `json:"integrated,omitempty"`
+	TruePeak   float64 `json:"true_peak,omitempty"`
+}
+
+// Subtitles is an SRT, VTT or ASS file either burned into the picture
+// (with optional styling) or muxed as a soft track.
+type Subtitles struct {
+	Path     string `json:"path"`
+	Mode     string `json:"mode,omitempty"`
+	FontSize int    `json:"font_size,omitempty"`
+	Color    string `json:"color,omitempty"`
+}
+
+// Visualizer draws the audio over a still image: a waveform, spectrum bars
+// or a circular vector scope, in a #RRGGBB color at the top, center or bottom.
+type Visualizer struct {
+	Style    string `json:"style"`
+	Color    string `json:"color,omitempty"`
+	Position string `json:"position,omitempty"`
 }
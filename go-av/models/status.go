@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobStatusProcessing JobStatus = "processing"
+	JobStatusReady      JobStatus = "ready"
+	JobStatusFailed     JobStatus = "failed"
+	// JobStatusCancelled is reported when a cancel request stopped the job.
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+const (
+	FailureCodeError   = "error"
+	FailureCodeTimeout = "timeout"
+)
+
+// CancelMessage asks every worker to stop a job; it is broadcast on the
+// cancel exchange.
+type CancelMessage struct {
+	UUID string `json:"uuid"`
+}
+
+type StatusEvent struct {
+	UUID      string     `json:"uuid"`
+	Status    JobStatus  `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Code      string     `json:"code,omitempty"`
+	WorkerID  string     `json:"worker_id"`
+	Progress  float64    `json:"progress"`
+	Result    *JobResult `json:"result,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// JobResult describes a finished job's output; it is sent with the ready event.
+type JobResult struct {
+	Loudness  *LoudnessStats `json:"loudness,omitempty"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+}
+
+// Artifact is an extra file uploaded under the job prefix next to the output,
+// such as a poster frame or a thumbnail sprite sheet.
+type Artifact struct {
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	ContentType string `json:"content_type"`
+}
+
+// LoudnessStats are the EBU R128 measurements of a loudnorm pass: the source
+// audio as measured in the first pass and the normalized audio of the second.
+type LoudnessStats struct {
+	InputIntegrated   float64 `json:"input_i"`
+	InputTruePeak     float64 `json:"input_tp"`
+	InputLRA          float64 `json:"input_lra"`
+	InputThreshold    float64 `json:"input_thresh"`
+	OutputIntegrated  float64 `json:"output_i"`
+	OutputTruePeak    float64 `json:"output_tp"`
+	OutputLRA         float64 `json:"output_lra"`
+	TargetIntegrated  float64 `json:"target_i"`
+	TargetTruePeak    float64 `json:"target_tp"`
+	NormalizationType string  `json:"normalization_type"`
+}
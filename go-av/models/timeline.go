@@ -0,0 +1,40 @@
+package models
+
+const (
+	TransitionCut       = "cut"
+	TransitionCrossfade = "crossfade"
+)
+
+// Timeline is the manifest of a timeline job. Asset names refer to files
+// uploaded alongside it under {uuid}/assets/.
+type Timeline struct {
+	// Width and Height override the output size picked from the first item.
+	Width     int            `json:"width,omitempty"`
+	Height    int            `json:"height,omitempty"`
+	Items     []TimelineItem `json:"items"`
+	Music     *TimelineAudio `json:"music,omitempty"`
+	Voiceover *TimelineAudio `json:"voiceover,omitempty"`
+	// Ducking lowers the music while the voiceover is speaking.
+	Ducking bool `json:"ducking,omitempty"`
+}
+
+// TimelineItem is an image or clip shown in sequence on the video track.
+type TimelineItem struct {
+	Asset string `json:"asset"`
+	// Duration is required for images; clips default to their remaining length.
+	Duration float64 `json:"duration,omitempty"`
+	// Start skips into a clip before it is shown.
+	Start float64 `json:"start,omitempty"`
+	// Transition joins the item to the previous one: cut (default) or crossfade.
+	Transition         string  `json:"transition,omitempty"`
+	TransitionDuration float64 `json:"transition_duration,omitempty"`
+}
+
+// TimelineAudio is a music or voiceover track laid over the whole timeline.
+type TimelineAudio struct {
+	Asset string `json:"asset"`
+	// Volume scales the track; 0 keeps it at 1.0.
+	Volume float64 `json:"volume,omitempty"`
+	// Start delays the track, in seconds from the beginning of the timeline.
+	Start float64 `json:"start,omitempty"`
+}
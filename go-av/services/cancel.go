@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrJobCancelled marks a job that was cancelled through the API.
+var ErrJobCancelled = errors.New("job cancelled")
+
+// cancelledRetention is how long a cancelled UUID is remembered, so a job
+// that is still queued or waiting for a retry is skipped when it arrives.
+const cancelledRetention = 24 * time.Hour
+
+// cancelMarkerDir is where the API records cancelled jobs in their bucket,
+// for workers that were not listening when the cancel went out.
+const cancelMarkerDir = ".cancelled/"
+
+// CancelRegistry tracks the jobs running on this worker so cancel requests
+// can interrupt them; interrupting a job kills its ffmpeg processes.
+type CancelRegistry struct {
+	mu        sync.Mutex
+	running   map[string]context.CancelCauseFunc
+	cancelled map[string]time.Time
+}
+
+func NewCancelRegistry() *CancelRegistry {
+	return &CancelRegistry{
+		running:   make(map[string]context.CancelCauseFunc),
+		cancelled: make(map[string]time.Time),
+	}
+}
+
+// Track returns a context for running a job that is cancelled with
+// ErrJobCancelled once the job is, including when it was cancelled before
+// Track was called. done must be called when the job ends.
+func (r *CancelRegistry) Track(ctx context.Context, uuid string) (context.Context, func()) {
+	jobCtx, cancel := context.WithCancelCause(ctx)
+
+	r.mu.Lock()
+	r.prune(time.Now())
+	if _, ok := r.cancelled[uuid]; ok {
+		cancel(ErrJobCancelled)
+	}
+	r.running[uuid] = cancel
+	r.mu.Unlock()
+
+	return jobCtx, func() {
+		r.mu.Lock()
+		delete(r.running, uuid)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Cancel interrupts the job if it runs here and remembers it either way. It
+// reports whether the job was running.
+func (r *CancelRegistry) Cancel(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(now)
+	r.cancelled[uuid] = now
+
+	cancel, ok := r.running[uuid]
+	if ok {
+		cancel(ErrJobCancelled)
+	}
+	return ok
+}
+
+// prune forgets cancels older than cancelledRetention. r.mu must be held.
+func (r *CancelRegistry) prune(now time.Time) {
+	for id, at := range r.cancelled {
+		if now.Sub(at) > cancelledRetention {
+			delete(r.cancelled, id)
+		}
+	}
+}
+
+// Cancelled reports whether the job was cancelled recently.
+func (r *CancelRegistry) Cancelled(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.cancelled[uuid]
+	return ok
+}
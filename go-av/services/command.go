@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// commandWaitDelay bounds how long Wait blocks on output pipes after the
+// process group has been killed.
+const commandWaitDelay = 5 * time.Second
+
+// newCommand builds an external command that is killed together with its
+// child processes when ctx is done.
+func newCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
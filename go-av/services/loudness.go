@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+
+	"github.com/resoul/avcompression/models"
+	"github.com/sirupsen/logrus"
+)
+
+// loudnessRange is the loudness range target (LRA) passed to loudnorm.
+const loudnessRange = 11.0
+
+// errSilentAudio is returned when the first pass finds nothing to normalize.
+var errSilentAudio = errors.New("audio is silent")
+
+// loudnormReport is the JSON block loudnorm prints with print_format=json.
+// Every value is a string.
+type loudnormReport struct {
+	InputI            string `json:"input_i"`
+	InputTP           string `json:"input_tp"`
+	InputLRA          string `json:"input_lra"`
+	InputThresh       string `json:"input_thresh"`
+	OutputI           string `json:"output_i"`
+	OutputTP          string `json:"output_tp"`
+	OutputLRA         string `json:"output_lra"`
+	NormalizationType string `json:"normalization_type"`
+	TargetOffset      string `json:"target_offset"`
+}
+
+// normalizeLoudness runs a two-pass loudnorm over audioPath: the first pass
+// measures the source, the second writes the normalized audio to outputPath
+// as FLAC so the encode that follows does no further processing.
+func (p *Processor) normalizeLoudness(ctx context.Context, audioPath, outputPath string, opts *models.Loudnorm) (*models.LoudnessStats, error) {
+	target, truePeak := p.loudnessTarget, p.truePeak
+	if opts.Integrated != 0 {
+		target = opts.Integrated
+	}
+	if opts.TruePeak != 0 {
+		truePeak = opts.TruePeak
+	}
+	base := fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", target, truePeak, loudnessRange)
+
+	measured, err := runLoudnorm(newCommand(ctx, "ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", audioPath,
+		"-vn",
+		"-af", base+":print_format=json",
+		"-f", "null",
+		"-",
+	))
+	if err != nil {
+		return nil, fmt.Errorf("measure loudness: %w", err)
+	}
+	if v, err := strconv.ParseFloat(measured.InputI, 64); err != nil || math.IsInf(v, -1) {
+		return nil, errSilentAudio
+	}
+
+	second := fmt.Sprintf("%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true:print_format=json",
+		base, measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.TargetOffset)
+
+	normalized, err := runLoudnorm(newCommand(ctx, "ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", audioPath,
+		"-vn",
+		// loudnorm resamples to 192 kHz internally.
+		"-af", second+",aresample=48000",
+		"-c:a", "flac",
+		"-y", outputPath,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("normalize loudness: %w", err)
+	}
+
+	stats := &models.LoudnessStats{
+		InputIntegrated:   parseLoudness(measured.InputI),
+		InputTruePeak:     parseLoudness(measured.InputTP),
+		InputLRA:          parseLoudness(measured.InputLRA),
+		InputThreshold:    parseLoudness(measured.InputThresh),
+		OutputIntegrated:  parseLoudness(normalized.OutputI),
+		OutputTruePeak:    parseLoudness(normalized.OutputTP),
+		OutputLRA:         parseLoudness(normalized.OutputLRA),
+		TargetIntegrated:  target,
+		TargetTruePeak:    truePeak,
+		NormalizationType: normalized.NormalizationType,
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"input_i":  stats.InputIntegrated,
+		"output_i": stats.OutputIntegrated,
+		"target_i": target,
+		"type":     stats.NormalizationType,
+	}).Debug("Audio loudness normalized")
+
+	return stats, nil
+}
+
+// runLoudnorm runs an ffmpeg loudnorm pass and parses the report it prints
+// at the end of stderr.
+func runLoudnorm(cmd *exec.Cmd) (*loudnormReport, error) {
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg execution failed: %w\nOutput: %s", err, output)
+	}
+
+	start := bytes.LastIndexByte(output, '{')
+	end := bytes.LastIndexByte(output, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudnorm report not found in ffmpeg output")
+	}
+
+	var report loudnormReport
+	if err := json.Unmarshal(output[start:end+1], &report); err != nil {
+		return nil, fmt.Errorf("parse loudnorm report: %w", err)
+	}
+
+	return &report, nil
+}
+
+// parseLoudness reads a loudnorm value. Values loudnorm reports as "-inf"
+// (digital silence) become 0, which JSON can carry.
+func parseLoudness(value string) float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0
+	}
+	return v
+}
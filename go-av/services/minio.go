@@ -26,6 +26,18 @@ func NewMinioService(cfg config.MinioConfig) (*MinioService, error) {
 	return &MinioService{client: client}, nil
 }
 
+// Exists reports whether the object is in the bucket.
+func (s *MinioService) Exists(ctx context.Context, bucket, object string) (bool, error) {
+	_, err := s.client.StatObject(ctx, bucket, object, minio.StatObjectOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
+	}
+	return false, fmt.Errorf("stat object failed (bucket=%s, object=%s): %w", bucket, object, err)
+}
+
 func (s *MinioService) DownloadFile(ctx context.Context, bucket, object, localPath string) error {
 	obj, err := s.client.GetObject(ctx, bucket, object, minio.GetObjectOptions{})
 	if err != nil {
@@ -46,7 +58,7 @@ func (s *MinioService) DownloadFile(ctx context.Context, bucket, object, localPa
 	return nil
 }
 
-func (s *MinioService) UploadFile(ctx context.Context, bucket, object, localPath string) error {
+func (s *MinioService) UploadFile(ctx context.Context, bucket, object, localPath, contentType string) error {
 	file, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("open local file failed (path=%s): %w", localPath, err)
@@ -59,7 +71,7 @@ func (s *MinioService) UploadFile(ctx context.Context, bucket, object, localPath
 	}
 
 	_, err = s.client.PutObject(ctx, bucket, object, file, stat.Size(), minio.PutObjectOptions{
-		ContentType: "video/mp4",
+		ContentType: contentType,
 	})
 	if err != nil {
 		return fmt.Errorf("put object failed (bucket=%s, object=%s): %w", bucket, object, err)
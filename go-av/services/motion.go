@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	MotionNone     = "none"
+	MotionZoomIn   = "zoom-in"
+	MotionZoomOut  = "zoom-out"
+	MotionPanLeft  = "pan-left"
+	MotionPanRight = "pan-right"
+	MotionRandom   = "random"
+
+	motionFrameRate = 30
+	// motionOversample renders zoompan on an enlarged frame so its integer
+	// crop offsets do not make slow moves judder.
+	motionOversample = 2
+)
+
+// Motion animates a still image from a start to an end framing. Zoom is the
+// magnification; X and Y place the crop window within the spare room left
+// by the zoom, from 0 (left/top edge) to 1 (right/bottom edge).
+type Motion struct {
+	Name      string
+	StartZoom float64
+	EndZoom   float64
+	StartX    float64
+	EndX      float64
+	StartY    float64
+	EndY      float64
+}
+
+var motions = map[string]Motion{
+	MotionZoomIn:   {Name: MotionZoomIn, StartZoom: 1, EndZoom: 1.25, StartX: 0.5, EndX: 0.5, StartY: 0.5, EndY: 0.5},
+	MotionZoomOut:  {Name: MotionZoomOut, StartZoom: 1.25, EndZoom: 1, StartX: 0.5, EndX: 0.5, StartY: 0.5, EndY: 0.5},
+	MotionPanLeft:  {Name: MotionPanLeft, StartZoom: 1.2, EndZoom: 1.2, StartX: 1, EndX: 0, StartY: 0.5, EndY: 0.5},
+	MotionPanRight: {Name: MotionPanRight, StartZoom: 1.2, EndZoom: 1.2, StartX: 0, EndX: 1, StartY: 0.5, EndY: 0.5},
+}
+
+// GetMotion looks up a motion effect by name; an empty name means none.
+// MotionRandom derives the effect from seed so retries render the same way.
+func GetMotion(name, seed string) (Motion, error) {
+	switch name {
+	case "", MotionNone:
+		return Motion{Name: MotionNone}, nil
+	case MotionRandom:
+		return randomMotion(seed), nil
+	}
+
+	motion, ok := motions[name]
+	if !ok {
+		return Motion{}, fmt.Errorf("unknown motion: %s", name)
+	}
+	return motion, nil
+}
+
+func randomMotion(seed string) Motion {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0))
+
+	names := []string{MotionZoomIn, MotionZoomOut, MotionPanLeft, MotionPanRight}
+	motion := motions[names[rng.IntN(len(names))]]
+	motion.Name = MotionRandom
+
+	between := func(lo, hi float64) float64 {
+		return lo + rng.Float64()*(hi-lo)
+	}
+
+	zoom := between(1.15, 1.35)
+	switch {
+	case motion.StartZoom < motion.EndZoom:
+		motion.EndZoom = zoom
+	case motion.StartZoom > motion.EndZoom:
+		motion.StartZoom = zoom
+	default:
+		motion.StartZoom, motion.EndZoom = zoom, zoom
+	}
+
+	// Drift towards a random focal point rather than the exact centre.
+	if motion.StartX == motion.EndX {
+		motion.EndX = between(0.3, 0.7)
+	}
+	motion.StartY = between(0.3, 0.7)
+	motion.EndY = between(0.3, 0.7)
+
+	return motion
+}
+
+// Static reports whether the image is shown without motion.
+func (m Motion) Static() bool {
+	return m.Name == MotionNone || m.Name == ""
+}
+
+// filter returns the video filter that covers width x height with the image
+// and animates it over duration seconds.
+func (m Motion) filter(width, height int, duration float64) string {
+	frames := max(int(math.Ceil(duration*motionFrameRate)), 1)
+	progress := fmt.Sprintf("on/%d", frames)
+
+	workW, workH := width*motionOversample, height*motionOversample
+	lerp := func(from, to float64) string {
+		return fmt.Sprintf("(%.4f%+.4f*%s)", from, to-from, progress)
+	}
+
+	return fmt.Sprintf(
+		"scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,"+
+			"zoompan=z='%s':x='(iw-iw/zoom)*%s':y='(ih-ih/zoom)*%s':d=%d:s=%dx%d:fps=%d",
+		workW, workH, workW, workH,
+		lerp(m.StartZoom, m.EndZoom), lerp(m.StartX, m.EndX), lerp(m.StartY, m.EndY),
+		frames, width, height, motionFrameRate,
+	)
+}
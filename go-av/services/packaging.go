@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	OutputModeFile    = "file"
+	OutputModeHLS     = "hls"
+	OutputModeHLSDASH = "hls+dash"
+
+	// StreamDir is the folder under the job prefix that holds the packaged stream.
+	StreamDir = "hls"
+
+	hlsMasterPlaylist = "master.m3u8"
+	dashManifest      = "manifest.mpd"
+	segmentSeconds    = 4
+	maxRenditions     = 4
+)
+
+// rungSizes are the short-side sizes offered below the top rendition.
+var rungSizes = []int{1440, 1080, 720, 480, 360}
+
+// Rendition is one variant of the adaptive-bitrate ladder.
+type Rendition struct {
+	Width   int
+	Height  int
+	MaxRate int // kbit/s
+}
+
+// IsStreamingMode reports whether an output mode packages segmented streams.
+func IsStreamingMode(mode string) bool {
+	return mode == OutputModeHLS || mode == OutputModeHLSDASH
+}
+
+// buildLadder derives renditions from the composed output size: the full
+// size first, then each standard size below it, keeping the aspect ratio.
+func (p *Processor) buildLadder(width, height int, preset Preset) []Rendition {
+	short := min(width, height)
+	ladder := []Rendition{{Width: width, Height: height, MaxRate: preset.MaxRate(width, height)}}
+
+	for _, size := range rungSizes {
+		if len(ladder) == maxRenditions {
+			break
+		}
+		if size >= short {
+			continue
+		}
+
+		w := evenDimension(float64(width) * float64(size) / float64(short))
+		h := evenDimension(float64(height) * float64(size) / float64(short))
+		ladder = append(ladder, Rendition{Width: w, Height: h, MaxRate: preset.MaxRate(w, h)})
+	}
+
+	return ladder
+}
+
+func evenDimension(v float64) int {
+	n := int(v+0.5) &^ 1
+	if n < 2 {
+		return 2
+	}
+	return n
+}
+
+// buildPackageCommand encodes the ladder from the composed video and writes
+// fMP4 segments with an HLS master playlist (plus a DASH manifest for
+// OutputModeHLSDASH) into outDir.
+func (p *Processor) buildPackageCommand(ctx context.Context, inputPath, outDir, mode string, ladder []Rendition, preset Preset) (*exec.Cmd, error) {
+	var filter strings.Builder
+	fmt.Fprintf(&filter, "[0:v]split=%d", len(ladder))
+	for i := range ladder {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	for i, r := range ladder {
+		fmt.Fprintf(&filter, ";[v%d]scale=%d:%d[v%dout]", i, r.Width, r.Height, i)
+	}
+
+	args := []string{
+		"-i", inputPath,
+		"-filter_complex", filter.String(),
+	}
+
+	for i, r := range ladder {
+		args = append(args, "-map", fmt.Sprintf("[v%dout]", i))
+		if r.MaxRate > 0 {
+			args = append(args,
+				fmt.Sprintf("-maxrate:v:%d", i), strconv.Itoa(r.MaxRate)+"k",
+				fmt.Sprintf("-bufsize:v:%d", i), strconv.Itoa(r.MaxRate*2)+"k",
+			)
+		}
+	}
+
+	args = append(args, preset.VideoArgs...)
+	args = append(args,
+		"-pix_fmt", "yuv420p",
+		// Keyframes on segment boundaries keep renditions switchable.
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segmentSeconds),
+		"-sc_threshold", "0",
+	)
+
+	switch mode {
+	case OutputModeHLS:
+		streamMap := make([]string, len(ladder))
+		for i := range ladder {
+			args = append(args, "-map", "0:a:0")
+			streamMap[i] = fmt.Sprintf("v:%d,a:%d", i, i)
+
+			if err := os.MkdirAll(filepath.Join(outDir, fmt.Sprintf("stream_%d", i)), os.ModePerm); err != nil {
+				return nil, fmt.Errorf("create stream dir: %w", err)
+			}
+		}
+		args = append(args, preset.AudioArgs...)
+		args = append(args,
+			"-f", "hls",
+			"-hls_time", strconv.Itoa(segmentSeconds),
+			"-hls_playlist_type", "vod",
+			"-hls_segment_type", "fmp4",
+			"-hls_flags", "independent_segments",
+			"-master_pl_name", hlsMasterPlaylist,
+			"-hls_segment_filename", filepath.Join(outDir, "stream_%v", "segment_%05d.m4s"),
+			"-var_stream_map", strings.Join(streamMap, " "),
+		)
+		args = append(args, ffmpegProgressArgs...)
+		args = append(args, "-y", filepath.Join(outDir, "stream_%v", "playlist.m3u8"))
+	case OutputModeHLSDASH:
+		args = append(args, "-map", "0:a:0")
+		args = append(args, preset.AudioArgs...)
+		args = append(args,
+			"-f", "dash",
+			"-seg_duration", strconv.Itoa(segmentSeconds),
+			"-use_template", "1",
+			"-use_timeline", "1",
+			"-hls_playlist", "1",
+			"-adaptation_sets", "id=0,streams=v id=1,streams=a",
+			"-init_seg_name", "init_$RepresentationID$.m4s",
+			"-media_seg_name", "chunk_$RepresentationID$_$Number%05d$.m4s",
+		)
+		args = append(args, ffmpegProgressArgs...)
+		args = append(args, "-y", filepath.Join(outDir, dashManifest))
+	default:
+		return nil, fmt.Errorf("unsupported output mode: %s", mode)
+	}
+
+	return newCommand(ctx, "ffmpeg", args...), nil
+}
+
+// uploadDir uploads every file below localDir to prefix, keeping relative paths.
+func (p *Processor) uploadDir(ctx context.Context, bucket, localDir, prefix string) (int, error) {
+	uploaded := 0
+	err := filepath.WalkDir(localDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+
+		object := prefix + "/" + filepath.ToSlash(rel)
+		if err := p.minio.UploadFile(ctx, bucket, object, path, streamContentType(path)); err != nil {
+			return err
+		}
+		uploaded++
+		return nil
+	})
+	return uploaded, err
+}
+
+func streamContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	case ".ts":
+		return "video/mp2t"
+	default:
+		return "application/octet-stream"
+	}
+}
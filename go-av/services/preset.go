@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const DefaultPreset = "h264-mp4"
+
+// Preset describes the encoders and container used for a job's output.
+type Preset struct {
+	Name        string
+	Extension   string
+	ContentType string
+	// VideoArgs selects and tunes the video encoder; empty for audio-only output.
+	VideoArgs []string
+	// StillImageArgs are added to VideoArgs when the source is a still image.
+	StillImageArgs []string
+	AudioArgs      []string
+	ContainerArgs  []string
+	// Ladder caps the video bitrate by the output's short side, lowest rung first.
+	Ladder []BitrateRung
+	// SubtitleCodec encodes soft subtitle tracks; empty when the container
+	// cannot carry them.
+	SubtitleCodec string
+	// ConstrainedQuality passes the cap as the target bitrate (libvpx CQ
+	// mode) instead of -maxrate/-bufsize.
+	ConstrainedQuality bool
+}
+
+// BitrateRung limits the video bitrate for outputs whose short side is at
+// most MaxSize pixels.
+type BitrateRung struct {
+	MaxSize int
+	MaxRate int // kbit/s
+}
+
+var presets = map[string]Preset{
+	"h264-mp4": {
+		Name:           "h264-mp4",
+		Extension:      "mp4",
+		ContentType:    "video/mp4",
+		VideoArgs:      []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+		StillImageArgs: []string{"-tune", "stillimage"},
+		AudioArgs:      []string{"-c:a", "aac", "-b:a", "192k"},
+		ContainerArgs:  []string{"-movflags", "+faststart"},
+		SubtitleCodec:  "mov_text",
+		Ladder:         defaultLadder,
+	},
+	"h265-mp4": {
+		Name:          "h265-mp4",
+		Extension:     "mp4",
+		ContentType:   "video/mp4",
+		VideoArgs:     []string{"-c:v", "libx265", "-preset", "medium", "-crf", "28", "-tag:v", "hvc1"},
+		AudioArgs:     []string{"-c:a", "aac", "-b:a", "192k"},
+		ContainerArgs: []string{"-movflags", "+faststart"},
+		SubtitleCodec: "mov_text",
+		Ladder:        scaleLadder(defaultLadder, 0.6),
+	},
+	"vp9-webm": {
+		Name:               "vp9-webm",
+		Extension:          "webm",
+		ContentType:        "video/webm",
+		VideoArgs:          []string{"-c:v", "libvpx-vp9", "-crf", "32", "-row-mt", "1", "-deadline", "good", "-cpu-used", "2"},
+		AudioArgs:          []string{"-c:a", "libopus", "-b:a", "128k"},
+		SubtitleCodec:      "webvtt",
+		Ladder:             scaleLadder(defaultLadder, 0.7),
+		ConstrainedQuality: true,
+	},
+	"av1-mkv": {
+		Name:          "av1-mkv",
+		Extension:     "mkv",
+		ContentType:   "video/x-matroska",
+		VideoArgs:     []string{"-c:v", "libsvtav1", "-crf", "35", "-preset", "8"},
+		AudioArgs:     []string{"-c:a", "libopus", "-b:a", "128k"},
+		SubtitleCodec: "ass",
+		Ladder:        scaleLadder(defaultLadder, 0.5),
+	},
+	"audio-only-m4a": {
+		Name:          "audio-only-m4a",
+		Extension:     "m4a",
+		ContentType:   "audio/mp4",
+		AudioArgs:     []string{"-c:a", "aac", "-b:a", "192k"},
+		ContainerArgs: []string{"-movflags", "+faststart"},
+	},
+}
+
+// defaultLadder holds H.264 bitrate caps for the standard output resolutions.
+var defaultLadder = []BitrateRung{
+	{MaxSize: 360, MaxRate: 800},
+	{MaxSize: 480, MaxRate: 1500},
+	{MaxSize: 720, MaxRate: 3000},
+	{MaxSize: 1080, MaxRate: 6000},
+	{MaxSize: 1440, MaxRate: 10000},
+	{MaxSize: 2160, MaxRate: 20000},
+}
+
+func scaleLadder(ladder []BitrateRung, factor float64) []BitrateRung {
+	scaled := make([]BitrateRung, len(ladder))
+	for i, rung := range ladder {
+		scaled[i] = BitrateRung{MaxSize: rung.MaxSize, MaxRate: int(float64(rung.MaxRate) * factor)}
+	}
+	return scaled
+}
+
+// GetPreset looks up a preset by name; an empty name selects DefaultPreset.
+func GetPreset(name string) (Preset, error) {
+	if name == "" {
+		name = DefaultPreset
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset: %s", name)
+	}
+	return preset, nil
+}
+
+// AudioOnly reports whether the preset drops the video stream.
+func (p Preset) AudioOnly() bool {
+	return len(p.VideoArgs) == 0
+}
+
+// OutputName is the object name of the rendered file inside the job prefix.
+func (p Preset) OutputName() string {
+	return "output." + p.Extension
+}
+
+// MaxRate returns the bitrate cap in kbit/s for an output size, or 0 when
+// the preset has no ladder.
+func (p Preset) MaxRate(width, height int) int {
+	if len(p.Ladder) == 0 {
+		return 0
+	}
+	short := min(width, height)
+	for _, rung := range p.Ladder {
+		if short <= rung.MaxSize {
+			return rung.MaxRate
+		}
+	}
+	return p.Ladder[len(p.Ladder)-1].MaxRate
+}
+
+// videoArgs returns the encoder arguments for a video of the given size.
+func (p Preset) videoArgs(width, height int, stillImage bool) []string {
+	args := append([]string{}, p.VideoArgs...)
+	if stillImage {
+		args = append(args, p.StillImageArgs...)
+	}
+	rate := p.MaxRate(width, height)
+	switch {
+	case rate == 0:
+	case p.ConstrainedQuality:
+		args = append(args, "-b:v", strconv.Itoa(rate)+"k")
+	default:
+		args = append(args,
+			"-maxrate", strconv.Itoa(rate)+"k",
+			"-bufsize", strconv.Itoa(rate*2)+"k",
+		)
+	}
+	return args
+}
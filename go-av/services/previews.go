@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/resoul/avcompression/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the preview artifacts uploaded next to the output.
+const (
+	ArtifactPoster     = "poster"
+	ArtifactSprites    = "sprites"
+	ArtifactThumbnails = "thumbnails"
+	ArtifactPreview    = "preview"
+
+	thumbnailWidth   = 160
+	spriteColumns    = 10
+	maxThumbnails    = 100
+	previewWidth     = 320
+	previewFrameRate = 10
+	previewSeconds   = 4.0
+)
+
+var previewContentTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"webp": "image/webp",
+	"gif":  "image/gif",
+	"vtt":  "text/vtt",
+}
+
+// generatePreviews renders a poster frame, a thumbnail sprite sheet with its
+// WebVTT track and a short animated preview from the encoded video, uploads
+// them under the job prefix and returns them as artifacts.
+func (p *Processor) generatePreviews(ctx context.Context, job models.JobMessage, videoPath, tmpDir string, width, height int) ([]models.Artifact, error) {
+	duration, err := p.getAudioDuration(ctx, videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("get output duration: %w", err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("output has no duration")
+	}
+
+	previewDir := filepath.Join(tmpDir, "previews")
+	if err := os.MkdirAll(previewDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create preview dir: %w", err)
+	}
+
+	// Skip the first moments, which are often a fade in or a black frame.
+	offset := duration / 10
+
+	poster := "poster." + p.posterFormat
+	if err := runPreview(p.buildPosterCommand(ctx, videoPath, filepath.Join(previewDir, poster), offset)); err != nil {
+		return nil, fmt.Errorf("create poster: %w", err)
+	}
+
+	sprites := "sprites.jpg"
+	thumbnails := "thumbnails.vtt"
+	thumbHeight := evenDimension(float64(thumbnailWidth) * float64(height) / float64(width))
+	sheet := p.planSprites(duration, thumbnailWidth, thumbHeight)
+	if err := runPreview(buildSpriteCommand(ctx, videoPath, filepath.Join(previewDir, sprites), sheet)); err != nil {
+		return nil, fmt.Errorf("create sprites: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(previewDir, thumbnails), []byte(sheet.webVTT(sprites, duration)), 0o644); err != nil {
+		return nil, fmt.Errorf("write thumbnail track: %w", err)
+	}
+
+	preview := "preview." + p.previewFormat
+	start, length := offset, min(previewSeconds, duration)
+	if start+length > duration {
+		start = duration - length
+	}
+	if err := runPreview(p.buildAnimatedPreviewCommand(ctx, videoPath, filepath.Join(previewDir, preview), start, length)); err != nil {
+		return nil, fmt.Errorf("create preview: %w", err)
+	}
+
+	artifacts := []models.Artifact{
+		{Name: ArtifactPoster, File: poster},
+		{Name: ArtifactSprites, File: sprites},
+		{Name: ArtifactThumbnails, File: thumbnails},
+		{Name: ArtifactPreview, File: preview},
+	}
+	for i := range artifacts {
+		a := &artifacts[i]
+		a.ContentType = previewContentTypes[strings.TrimPrefix(filepath.Ext(a.File), ".")]
+
+		object := job.UUID + "/" + a.File
+		if err := p.minio.UploadFile(ctx, job.Bucket, object, filepath.Join(previewDir, a.File), a.ContentType); err != nil {
+			return nil, fmt.Errorf("upload %s: %w", a.Name, err)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"thumbnails": sheet.count,
+		"interval":   sheet.interval,
+	}).Debug("Previews uploaded")
+
+	return artifacts, nil
+}
+
+func (p *Processor) buildPosterCommand(ctx context.Context, videoPath, outputPath string, offset float64) *exec.Cmd {
+	args := []string{
+		"-hide_banner",
+		"-ss", formatSeconds(offset),
+		"-i", videoPath,
+		"-frames:v", "1",
+	}
+	if p.posterFormat == "webp" {
+		args = append(args, "-c:v", "libwebp", "-quality", "80")
+	} else {
+		args = append(args, "-q:v", "2")
+	}
+	args = append(args, "-y", outputPath)
+
+	return newCommand(ctx, "ffmpeg", args...)
+}
+
+// spriteSheet lays out count thumbnails taken every interval seconds on a
+// grid of columns x rows tiles.
+type spriteSheet struct {
+	interval      float64
+	count         int
+	columns, rows int
+	width, height int
+}
+
+// planSprites spaces thumbnails APP_THUMBNAIL_INTERVAL apart, stretching the
+// interval on long outputs so the sheet holds at most maxThumbnails.
+func (p *Processor) planSprites(duration float64, width, height int) spriteSheet {
+	interval := p.thumbnailInterval.Seconds()
+	count := max(int(math.Ceil(duration/interval)), 1)
+	if count > maxThumbnails {
+		count = maxThumbnails
+		interval = duration / maxThumbnails
+	}
+
+	columns := min(count, spriteColumns)
+	return spriteSheet{
+		interval: interval,
+		count:    count,
+		columns:  columns,
+		rows:     (count + columns - 1) / columns,
+		width:    width,
+		height:   height,
+	}
+}
+
+func buildSpriteCommand(ctx context.Context, videoPath, outputPath string, sheet spriteSheet) *exec.Cmd {
+	return newCommand(ctx, "ffmpeg",
+		"-hide_banner",
+		"-i", videoPath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=1/%s,scale=%d:%d,tile=%dx%d",
+			formatSeconds(sheet.interval), sheet.width, sheet.height, sheet.columns, sheet.rows),
+		"-frames:v", "1",
+		"-q:v", "4",
+		"-y", outputPath,
+	)
+}
+
+// webVTT returns a thumbnail track pointing each interval at its tile in
+// the sprite image, using media fragment coordinates.
+func (s spriteSheet) webVTT(sprite string, duration float64) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	for i := range s.count {
+		start := float64(i) * s.interval
+		end := min(start+s.interval, duration)
+		x := (i % s.columns) * s.width
+		y := (i / s.columns) * s.height
+
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			vttTimestamp(start), vttTimestamp(end), sprite, x, y, s.width, s.height)
+	}
+
+	return b.String()
+}
+
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func (p *Processor) buildAnimatedPreviewCommand(ctx context.Context, videoPath, outputPath string, start, length float64) *exec.Cmd {
+	scale := fmt.Sprintf("fps=%d,scale=%d:-2:flags=lanczos", previewFrameRate, previewWidth)
+
+	args := []string{
+		"-hide_banner",
+		"-ss", formatSeconds(start),
+		"-t", formatSeconds(length),
+		"-i", videoPath,
+		"-an",
+	}
+	if p.previewFormat == "gif" {
+		// A palette built from the clip itself keeps GIF banding down.
+		args = append(args, "-vf", scale+",split[a][b];[a]palettegen[p];[b][p]paletteuse")
+	} else {
+		args = append(args, "-vf", scale, "-c:v", "libwebp", "-quality", "70")
+	}
+	args = append(args, "-loop", "0", "-y", outputPath)
+
+	return newCommand(ctx, "ffmpeg", args...)
+}
+
+// runPreview runs a short ffmpeg command that reports no progress.
+func runPreview(cmd *exec.Cmd) error {
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg execution failed: %w\nOutput: %s", err, output)
+	}
+	return nil
+}
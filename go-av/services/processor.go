@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,12 +16,32 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 
+	"github.com/resoul/avcompression/config"
 	"github.com/resoul/avcompression/models"
 	"github.com/sirupsen/logrus"
 )
 
 type Processor struct {
-	minio *MinioService
+	minio             *MinioService
+	publisher         StatusPublisher
+	cancels           *CancelRegistry
+	workerID          string
+	timeout           time.Duration
+	progressInterval  time.Duration
+	loudnessTarget    float64
+	truePeak          float64
+	previews          bool
+	thumbnailInterval time.Duration
+	posterFormat      string
+	previewFormat     string
+}
+
+// ErrJobTimeout marks a job that was aborted because it exceeded APP_TIMEOUT.
+var ErrJobTimeout = errors.New("job timed out")
+
+// StatusPublisher delivers job status events back to the API.
+type StatusPublisher interface {
+	PublishStatus(ctx context.Context, event models.StatusEvent) error
 }
 
 type MediaType string
@@ -37,13 +59,24 @@ type MediaInfo struct {
 	HasAudio bool
 }
 
-func NewProcessor(minio *MinioService) *Processor {
+func NewProcessor(minio *MinioService, publisher StatusPublisher, cancels *CancelRegistry, cfg config.AppConfig) *Processor {
 	return &Processor{
-		minio: minio,
+		minio:             minio,
+		publisher:         publisher,
+		cancels:           cancels,
+		workerID:          cfg.WorkerID,
+		timeout:           cfg.Timeout,
+		progressInterval:  cfg.ProgressInterval,
+		loudnessTarget:    cfg.LoudnessTarget,
+		truePeak:          cfg.TruePeak,
+		previews:          cfg.Previews,
+		thumbnailInterval: cfg.ThumbnailInterval,
+		posterFormat:      cfg.PosterFormat,
+		previewFormat:     cfg.PreviewFormat,
 	}
 }
 
-func (p *Processor) HandleJob(job models.JobMessage) {
+func (p *Processor) HandleJob(ctx context.Context, job models.JobMessage) error {
 	startTime := time.Now()
 
 	log := logrus.WithFields(logrus.Fields{
@@ -51,22 +84,140 @@ func (p *Processor) HandleJob(job models.JobMessage) {
 		"bucket":   job.Bucket,
 	})
 
+	// The cancel broadcast only reaches workers listening at the time; the
+	// marker covers jobs picked up later, such as after a restart.
+	cancelled := p.cancels.Cancelled(job.UUID)
+	if !cancelled {
+		var err error
+		cancelled, err = p.minio.Exists(ctx, job.Bucket, cancelMarkerDir+job.UUID)
+		if err != nil {
+			return fmt.Errorf("check cancel marker: %w", err)
+		}
+		if cancelled {
+			p.cancels.Cancel(job.UUID)
+		}
+	}
+	if cancelled {
+		log.Info("Skipping cancelled job")
+		return ErrJobCancelled
+	}
+
 	log.Info("Processing job started")
+	p.reportStatus(job, models.JobStatusProcessing, 0, nil)
 
-	if err := p.processJob(job); err != nil {
+	runCtx, done := p.cancels.Track(ctx, job.UUID)
+	defer done()
+
+	jobCtx, cancel := context.WithTimeout(runCtx, p.timeout)
+	defer cancel()
+
+	result, err := p.processJob(jobCtx, job)
+	if err != nil {
+		if errors.Is(context.Cause(runCtx), ErrJobCancelled) {
+			log.WithField("duration", time.Since(startTime)).Info("Job cancelled")
+			p.reportStatus(job, models.JobStatusCancelled, 0, nil)
+			return ErrJobCancelled
+		}
+		if errors.Is(jobCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%w after %s: %v", ErrJobTimeout, p.timeout, err)
+		}
 		log.WithError(err).WithField("duration", time.Since(startTime)).Error("Job processing failed")
-		return
+		return err
 	}
 
 	log.WithField("duration", time.Since(startTime)).Info("Job processing completed")
+	p.reportReady(job, result)
+	return nil
+}
+
+// HandleDeadLetter reports a job as failed once no more retries are left.
+func (p *Processor) HandleDeadLetter(job models.JobMessage, err error) {
+	p.reportStatus(job, models.JobStatusFailed, 0, err)
+}
+
+// reportReady reports a finished job together with what was measured while
+// rendering it.
+func (p *Processor) reportReady(job models.JobMessage, result *models.JobResult) {
+	event := p.statusEvent(job, models.JobStatusReady, 100, nil)
+	event.Result = result
+	p.publishStatus(job, event)
+}
+
+func (p *Processor) reportStatus(job models.JobMessage, status models.JobStatus, progress float64, jobErr error) {
+	p.publishStatus(job, p.statusEvent(job, status, progress, jobErr))
+}
+
+func (p *Processor) statusEvent(job models.JobMessage, status models.JobStatus, progress float64, jobErr error) models.StatusEvent {
+	event := models.StatusEvent{
+		UUID:      job.UUID,
+		Status:    status,
+		WorkerID:  p.workerID,
+		Progress:  progress,
+		Timestamp: time.Now().UTC(),
+	}
+	if jobErr != nil {
+		event.Error = failureReason(jobErr)
+		event.Code = models.FailureCodeError
+		if errors.Is(jobErr, ErrJobTimeout) {
+			event.Code = models.FailureCodeTimeout
+		}
+	}
+
+	return event
+}
+
+func (p *Processor) publishStatus(job models.JobMessage, event models.StatusEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.publisher.PublishStatus(ctx, event); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"job_uuid": job.UUID,
+			"status":   event.Status,
+		}).Warn("Failed to publish job status")
+	}
+}
+
+// failureReason trims ffmpeg output out of the error so the reason stays readable.
+func failureReason(err error) string {
+	const maxLen = 1024
+
+	reason := err.Error()
+	if i := strings.Index(reason, "\nOutput:"); i >= 0 {
+		reason = reason[:i]
+	}
+	if len(reason) > maxLen {
+		reason = reason[:maxLen]
+	}
+	return reason
 }
 
-func (p *Processor) processJob(job models.JobMessage) error {
-	ctx := context.Background()
+func (p *Processor) processJob(ctx context.Context, job models.JobMessage) (*models.JobResult, error) {
+	preset, err := GetPreset(job.Preset)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := job.Output
+	if mode == "" {
+		mode = OutputModeFile
+	}
+	streaming := IsStreamingMode(mode)
+	if streaming && preset.Name != "h264-mp4" && preset.Name != "h265-mp4" {
+		return nil, fmt.Errorf("output mode %s requires an h264-mp4 or h265-mp4 preset", mode)
+	}
+	if !streaming && mode != OutputModeFile {
+		return nil, fmt.Errorf("unknown output mode: %s", mode)
+	}
+
+	encodeEnd := progressEncoded
+	if streaming {
+		encodeEnd = (progressDownloaded + progressEncoded) / 2
+	}
 
 	tmpDir := filepath.Join("/tmp", job.UUID)
 	if err := os.MkdirAll(tmpDir, os.ModePerm); err != nil {
-		return fmt.Errorf("create temp dir: %w", err)
+		return nil, fmt.Errorf("create temp dir: %w", err)
 	}
 	defer func() {
 		if err := os.RemoveAll(tmpDir); err != nil {
@@ -74,21 +225,96 @@ func (p *Processor) processJob(job models.JobMessage) error {
 		}
 	}()
 
+	result := &models.JobResult{}
+	outputLocal := filepath.Join(tmpDir, preset.OutputName())
+	onProgress := p.encodeProgress(job, progressDownloaded, encodeEnd)
+
+	var width, height int
+	switch job.Type {
+	case "", models.JobTypeCompose:
+		width, height, err = p.composeMedia(ctx, job, tmpDir, outputLocal, preset, result, onProgress)
+	case models.JobTypeTimeline:
+		width, height, err = p.composeTimeline(ctx, job, tmpDir, outputLocal, preset, result, onProgress)
+	default:
+		err = fmt.Errorf("unknown job type: %s", job.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.reportStatus(job, models.JobStatusProcessing, encodeEnd, nil)
+
+	if streaming {
+		if err := p.packageStream(ctx, job, mode, outputLocal, tmpDir, width, height, preset, encodeEnd); err != nil {
+			return nil, fmt.Errorf("package stream: %w", err)
+		}
+		p.reportStatus(job, models.JobStatusProcessing, progressEncoded, nil)
+	}
+
+	if p.previews && !preset.AudioOnly() {
+		artifacts, err := p.generatePreviews(ctx, job, outputLocal, tmpDir, width, height)
+		if err != nil {
+			return nil, fmt.Errorf("generate previews: %w", err)
+		}
+		result.Artifacts = artifacts
+	}
+
+	outputObj := filepath.Join(job.UUID, preset.OutputName())
+	if err := p.minio.UploadFile(ctx, job.Bucket, outputObj, outputLocal, preset.ContentType); err != nil {
+		return nil, fmt.Errorf("upload video: %w", err)
+	}
+	logrus.WithField("path", outputObj).Debug("Video uploaded")
+
+	return result, nil
+}
+
+// composeMedia downloads the job's media and audio and renders them into
+// outputPath. It returns the output size.
+func (p *Processor) composeMedia(ctx context.Context, job models.JobMessage, tmpDir, outputPath string, preset Preset, result *models.JobResult, onProgress func(percent float64)) (int, int, error) {
+	motion, err := GetMotion(job.Motion, job.UUID)
+	if err != nil {
+		return 0, 0, err
+	}
+	viz, err := resolveVisualizer(job.Visualizer)
+	if err != nil {
+		return 0, 0, err
+	}
+	subs, err := p.prepareSubtitles(ctx, job, tmpDir, preset)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	mediaLocal := filepath.Join(tmpDir, filepath.Base(job.MediaPath))
 	if err := p.minio.DownloadFile(ctx, job.Bucket, job.MediaPath, mediaLocal); err != nil {
-		return fmt.Errorf("download media: %w", err)
+		return 0, 0, fmt.Errorf("download media: %w", err)
 	}
 	logrus.WithField("file", filepath.Base(job.MediaPath)).Debug("Downloaded media")
 
 	audioLocal := filepath.Join(tmpDir, filepath.Base(job.AudioPath))
 	if err := p.minio.DownloadFile(ctx, job.Bucket, job.AudioPath, audioLocal); err != nil {
-		return fmt.Errorf("download audio: %w", err)
+		return 0, 0, fmt.Errorf("download audio: %w", err)
 	}
 	logrus.WithField("file", filepath.Base(job.AudioPath)).Debug("Downloaded audio")
 
-	mediaInfo, err := p.analyzeMedia(mediaLocal)
-	if err != nil {
-		return fmt.Errorf("analyze media: %w", err)
+	if job.Loudnorm != nil {
+		normalizedLocal := filepath.Join(tmpDir, "normalized.flac")
+		stats, err := p.normalizeLoudness(ctx, audioLocal, normalizedLocal, job.Loudnorm)
+		switch {
+		case errors.Is(err, errSilentAudio):
+			logrus.Warn("Audio is silent, skipping loudness normalization")
+		case err != nil:
+			return 0, 0, err
+		default:
+			audioLocal = normalizedLocal
+			result.Loudness = stats
+		}
+	}
+	p.reportStatus(job, models.JobStatusProcessing, progressDownloaded, nil)
+
+	mediaInfo, probed := probedMedia(job.MediaInfo)
+	if !probed {
+		if mediaInfo, err = p.analyzeMedia(ctx, mediaLocal); err != nil {
+			return 0, 0, fmt.Errorf("analyze media: %w", err)
+		}
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -97,25 +323,116 @@ func (p *Processor) processJob(job models.JobMessage) error {
 		"height":    mediaInfo.Height,
 		"duration":  mediaInfo.Duration,
 		"has_audio": mediaInfo.HasAudio,
+		"probed":    probed,
 	}).Debug("Media analyzed")
 
-	outputLocal := filepath.Join(tmpDir, "output.mp4")
-	resolution, err := p.createVideo(mediaLocal, audioLocal, outputLocal, mediaInfo)
+	resolution, err := p.createVideo(ctx, mediaLocal, audioLocal, outputPath, mediaInfo, preset, motion, viz, subs, onProgress)
 	if err != nil {
-		return fmt.Errorf("create video: %w", err)
+		return 0, 0, fmt.Errorf("create video: %w", err)
 	}
-	logrus.WithField("resolution", resolution).Debug("Video created")
+	logrus.WithFields(logrus.Fields{
+		"resolution": resolution,
+		"preset":     preset.Name,
+		"motion":     motion.Name,
+	}).Debug("Video created")
 
-	outputObj := filepath.Join(job.UUID, "output.mp4")
-	if err := p.minio.UploadFile(ctx, job.Bucket, outputObj, outputLocal); err != nil {
-		return fmt.Errorf("upload video: %w", err)
+	width, height := p.calculateTargetResolution(mediaInfo.Width, mediaInfo.Height)
+	return width, height, nil
+}
+
+// packageStream encodes the composed video into an ABR ladder, segments it
+// and uploads the result under {uuid}/hls/.
+func (p *Processor) packageStream(ctx context.Context, job models.JobMessage, mode, composedPath, tmpDir string, width, height int, preset Preset, progressStart float64) error {
+	duration, err := p.getAudioDuration(ctx, composedPath)
+	if err != nil {
+		return fmt.Errorf("get output duration: %w", err)
 	}
-	logrus.WithField("path", outputObj).Debug("Video uploaded")
+
+	ladder := p.buildLadder(width, height, preset)
+
+	streamLocal := filepath.Join(tmpDir, StreamDir)
+	if err := os.MkdirAll(streamLocal, os.ModePerm); err != nil {
+		return fmt.Errorf("create stream dir: %w", err)
+	}
+
+	cmd, err := p.buildPackageCommand(ctx, composedPath, streamLocal, mode, ladder, preset)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"mode":       mode,
+		"renditions": len(ladder),
+	}).Debug("Packaging stream")
+
+	if err := runFFmpeg(cmd, duration, p.encodeProgress(job, progressStart, progressEncoded)); err != nil {
+		return err
+	}
+
+	prefix := job.UUID + "/" + StreamDir
+	uploaded, err := p.uploadDir(ctx, job.Bucket, streamLocal, prefix)
+	if err != nil {
+		return fmt.Errorf("upload stream: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"path":  prefix,
+		"files": uploaded,
+	}).Debug("Stream uploaded")
 
 	return nil
 }
 
-func (p *Processor) analyzeMedia(mediaPath string) (*MediaInfo, error) {
+// encodeProgress returns an ffmpeg progress callback that publishes throttled
+// status events, scaled into the [from, to] stage of the overall job progress.
+func (p *Processor) encodeProgress(job models.JobMessage, from, to float64) func(percent float64) {
+	var (
+		lastSent    time.Time
+		lastPercent float64
+	)
+
+	return func(percent float64) {
+		if percent < 100 && time.Since(lastSent) < p.progressInterval {
+			return
+		}
+		if percent-lastPercent < 1 && percent < 100 {
+			return
+		}
+		lastSent = time.Now()
+		lastPercent = percent
+
+		overall := from + percent*(to-from)/100
+		p.reportStatus(job, models.JobStatusProcessing, math.Round(overall*10)/10, nil)
+	}
+}
+
+// probedMedia takes the media info from the API's upload probe, so the
+// input need not be analyzed again. It reports false when there is no probe
+// or it lacks the size or duration the render needs.
+func probedMedia(probe *models.MediaInfo) (*MediaInfo, bool) {
+	if probe == nil || probe.Width <= 0 || probe.Height <= 0 {
+		return nil, false
+	}
+
+	switch MediaType(probe.Type) {
+	case MediaTypeImage:
+		return &MediaInfo{Type: MediaTypeImage, Width: probe.Width, Height: probe.Height}, true
+	case MediaTypeVideo:
+		if probe.Duration <= 0 {
+			return nil, false
+		}
+		return &MediaInfo{
+			Type:     MediaTypeVideo,
+			Width:    probe.Width,
+			Height:   probe.Height,
+			Duration: probe.Duration,
+			HasAudio: probe.HasAudio,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+func (p *Processor) analyzeMedia(ctx context.Context, mediaPath string) (*MediaInfo, error) {
 	if p.isImage(mediaPath) {
 		width, height, err := p.getImageDimensions(mediaPath)
 		if err != nil {
@@ -128,7 +445,7 @@ func (p *Processor) analyzeMedia(mediaPath string) (*MediaInfo, error) {
 		}, nil
 	}
 
-	return p.getVideoInfo(mediaPath)
+	return p.getVideoInfo(ctx, mediaPath)
 }
 
 func (p *Processor) isImage(path string) bool {
@@ -151,8 +468,8 @@ func (p *Processor) getImageDimensions(imagePath string) (int, int, error) {
 	return img.Width, img.Height, nil
 }
 
-func (p *Processor) getVideoInfo(videoPath string) (*MediaInfo, error) {
-	cmd := exec.Command("ffprobe",
+func (p *Processor) getVideoInfo(ctx context.Context, videoPath string) (*MediaInfo, error) {
+	cmd := newCommand(ctx, "ffprobe",
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_streams",
@@ -199,8 +516,8 @@ func (p *Processor) getVideoInfo(videoPath string) (*MediaInfo, error) {
 	return info, nil
 }
 
-func (p *Processor) createVideo(mediaPath, audioPath, outputPath string, mediaInfo *MediaInfo) (string, error) {
-	audioDuration, err := p.getAudioDuration(audioPath)
+func (p *Processor) createVideo(ctx context.Context, mediaPath, audioPath, outputPath string, mediaInfo *MediaInfo, preset Preset, motion Motion, viz *visualizer, subs *subtitleTrack, onProgress func(percent float64)) (string, error) {
+	audioDuration, err := p.getAudioDuration(ctx, audioPath)
 	if err != nil {
 		return "", fmt.Errorf("get audio duration: %w", err)
 	}
@@ -216,44 +533,78 @@ func (p *Processor) createVideo(mediaPath, audioPath, outputPath string, mediaIn
 		"media_duration": mediaInfo.Duration,
 	}).Debug("Target resolution and duration calculated")
 
+	targetDuration := audioDuration
+	if mediaInfo.Type == MediaTypeVideo && mediaInfo.Duration > audioDuration {
+		targetDuration = mediaInfo.Duration
+	}
+
 	var cmd *exec.Cmd
 
-	if mediaInfo.Type == MediaTypeImage {
-		cmd = p.buildImageCommand(mediaPath, audioPath, outputPath, targetWidth, targetHeight, audioDuration)
-	} else {
-		cmd = p.buildVideoCommand(mediaPath, audioPath, outputPath, targetWidth, targetHeight, audioDuration, mediaInfo)
+	switch {
+	case preset.AudioOnly():
+		cmd = p.buildAudioCommand(ctx, audioPath, outputPath, preset)
+		targetDuration = audioDuration
+		resolution = "audio"
+	case mediaInfo.Type == MediaTypeImage:
+		cmd = p.buildImageCommand(ctx, mediaPath, audioPath, outputPath, targetWidth, targetHeight, audioDuration, preset, motion, viz, subs)
+	default:
+		cmd = p.buildVideoCommand(ctx, mediaPath, audioPath, outputPath, targetWidth, targetHeight, audioDuration, mediaInfo, preset, subs)
 	}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("ffmpeg execution failed: %w\nOutput: %s", err, string(output))
+	if err := runFFmpeg(cmd, targetDuration, onProgress); err != nil {
+		return "", err
 	}
 
 	return resolution, nil
 }
 
-func (p *Processor) buildImageCommand(imagePath, audioPath, outputPath string, width, height int, audioDuration float64) *exec.Cmd {
-	scaleFilter := fmt.Sprintf("scale=%d:%d", width, height)
-
-	return exec.Command("ffmpeg",
-		"-loop", "1",
+func (p *Processor) buildImageCommand(ctx context.Context, imagePath, audioPath, outputPath string, width, height int, audioDuration float64, preset Preset, motion Motion, viz *visualizer, subs *subtitleTrack) *exec.Cmd {
+	var args []string
+	imageFilter := fmt.Sprintf("scale=%d:%d", width, height)
+	if motion.Static() {
+		args = append(args, "-loop", "1")
+	} else {
+		// zoompan turns the single decoded frame into the whole clip.
+		imageFilter = motion.filter(width, height, audioDuration)
+	}
+	args = append(args,
 		"-i", imagePath,
 		"-i", audioPath,
-		"-vf", scaleFilter,
-		"-c:v", "libx264",
-		"-tune", "stillimage",
-		"-c:a", "aac",
-		"-b:a", "192k",
+	)
+	args = append(args, subs.inputArgs()...)
+
+	if viz == nil {
+		args = append(args, "-vf", imageFilter+subs.filter())
+		if subs != nil && !subs.burnsIn() {
+			// Explicit maps keep the subtitle input out of automatic selection.
+			args = append(args, "-map", "0:v", "-map", "1:a")
+		}
+	} else {
+		vizFilter, position := viz.filter("[1:a]", "[viz]", width, height)
+		args = append(args,
+			"-filter_complex", fmt.Sprintf("[0:v]%s,fps=%d[bg];%s;[bg][viz]overlay=%s:format=auto%s[v]",
+				imageFilter, visualizerFrameRate, vizFilter, position, subs.filter()),
+			"-map", "[v]",
+			"-map", "1:a",
+		)
+	}
+	args = append(args, subs.outputArgs()...)
+	args = append(args, preset.videoArgs(width, height, motion.Static() && viz == nil && !subs.burnsIn())...)
+	args = append(args, preset.AudioArgs...)
+	args = append(args,
 		"-pix_fmt", "yuv420p",
 		"-color_range", "tv",
 		"-colorspace", "bt709",
 		"-t", fmt.Sprintf("%.2f", audioDuration),
-		"-y",
-		outputPath,
 	)
+	args = append(args, preset.ContainerArgs...)
+	args = append(args, ffmpegProgressArgs...)
+	args = append(args, "-y", outputPath)
+
+	return newCommand(ctx, "ffmpeg", args...)
 }
 
-func (p *Processor) buildVideoCommand(videoPath, audioPath, outputPath string, width, height int, audioDuration float64, mediaInfo *MediaInfo) *exec.Cmd {
+func (p *Processor) buildVideoCommand(ctx context.Context, videoPath, audioPath, outputPath string, width, height int, audioDuration float64, mediaInfo *MediaInfo, preset Preset, subs *subtitleTrack) *exec.Cmd {
 	scaleFilter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", width, height, width, height)
 
 	videoDuration := mediaInfo.Duration
@@ -271,28 +622,45 @@ func (p *Processor) buildVideoCommand(videoPath, audioPath, outputPath string, w
 	args := []string{
 		"-i", videoPath,
 		"-i", audioPath,
+	}
+	args = append(args, subs.inputArgs()...)
+	args = append(args,
 		"-filter_complex",
-		fmt.Sprintf("[0:v]%s,setpts=PTS-STARTPTS[v];[1:a]apad[a]", videoFilter),
+		fmt.Sprintf("[0:v]%s,setpts=PTS-STARTPTS%s[v];[1:a]apad[a]", videoFilter, subs.filter()),
 		"-map", "[v]",
 		"-map", "[a]",
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "23",
-		"-c:a", "aac",
-		"-b:a", "192k",
+	)
+	args = append(args, subs.outputArgs()...)
+	args = append(args, preset.videoArgs(width, height, false)...)
+	args = append(args, preset.AudioArgs...)
+	args = append(args,
 		"-pix_fmt", "yuv420p",
 		"-color_range", "tv",
 		"-colorspace", "bt709",
 		"-t", fmt.Sprintf("%.2f", maxDuration),
-		"-y",
-		outputPath,
+	)
+	args = append(args, preset.ContainerArgs...)
+	args = append(args, ffmpegProgressArgs...)
+	args = append(args, "-y", outputPath)
+
+	return newCommand(ctx, "ffmpeg", args...)
+}
+
+func (p *Processor) buildAudioCommand(ctx context.Context, audioPath, outputPath string, preset Preset) *exec.Cmd {
+	args := []string{
+		"-i", audioPath,
+		"-vn",
 	}
+	args = append(args, preset.AudioArgs...)
+	args = append(args, preset.ContainerArgs...)
+	args = append(args, ffmpegProgressArgs...)
+	args = append(args, "-y", outputPath)
 
-	return exec.Command("ffmpeg", args...)
+	return newCommand(ctx, "ffmpeg", args...)
 }
 
-func (p *Processor) getAudioDuration(audioPath string) (float64, error) {
-	cmd := exec.Command("ffprobe",
+func (p *Processor) getAudioDuration(ctx context.Context, audioPath string) (float64, error) {
+	cmd := newCommand(ctx, "ffprobe",
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Overall job progress is split into stages; the ffmpeg encode is mapped
+// into the range between downloading the inputs and uploading the output.
+const (
+	progressDownloaded = 10.0
+	progressEncoded    = 90.0
+)
+
+// ffmpegProgressArgs makes ffmpeg write key=value progress blocks to stdout.
+var ffmpegProgressArgs = []string{"-progress", "pipe:1", "-nostats"}
+
+// runFFmpeg runs an ffmpeg command started with ffmpegProgressArgs and calls
+// onProgress with the percentage of duration (in seconds) encoded so far.
+func runFFmpeg(cmd *exec.Cmd, duration float64, onProgress func(percent float64)) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("ffmpeg stdout pipe failed: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg start failed: %w", err)
+	}
+
+	parseProgress(stdout, duration, onProgress)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg execution failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+func parseProgress(r io.Reader, duration float64, onProgress func(percent float64)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		// Despite its name, out_time_ms is reported in microseconds.
+		case "out_time_ms", "out_time_us":
+			us, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || duration <= 0 {
+				continue
+			}
+			percent := float64(us) / 1e6 / duration * 100
+			onProgress(math.Max(0, math.Min(percent, 100)))
+		case "progress":
+			if value == "end" {
+				onProgress(100)
+			}
+		}
+	}
+
+	// Drain whatever is left so ffmpeg never blocks on a full pipe.
+	io.Copy(io.Discard, r)
+}
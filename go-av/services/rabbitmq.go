@@ -1,8 +1,12 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/resoul/avcompression/config"
@@ -11,12 +15,24 @@ import (
 )
 
 type RabbitMQService struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   amqp.Queue
+	conn            *amqp.Connection
+	channel         *amqp.Channel
+	queue           amqp.Queue
+	statusExchange  string
+	deadLetterQueue string
+	cancelExchange  string
+	retry           RetryPolicy
 }
 
-func NewRabbitMQService(cfg config.RabbitMQConfig) (*RabbitMQService, error) {
+// JobHandler processes jobs delivered by the consumer. HandleJob errors are
+// retried according to the RetryPolicy; HandleDeadLetter is called once a
+// job has exhausted its retries.
+type JobHandler interface {
+	HandleJob(ctx context.Context, job models.JobMessage) error
+	HandleDeadLetter(job models.JobMessage, err error)
+}
+
+func NewRabbitMQService(cfg config.RabbitMQConfig, retry RetryPolicy) (*RabbitMQService, error) {
 	conn, err := amqp.Dial(cfg.URL)
 	if err != nil {
 		return nil, fmt.Errorf("dial rabbitmq failed: %w", err)
@@ -28,8 +44,26 @@ func NewRabbitMQService(cfg config.RabbitMQConfig) (*RabbitMQService, error) {
 		return nil, fmt.Errorf("open channel failed: %w", err)
 	}
 
-	q, err := ch.QueueDeclare(
-		cfg.QueueName,
+	s := &RabbitMQService{
+		conn:            conn,
+		channel:         ch,
+		statusExchange:  cfg.StatusExchange,
+		deadLetterQueue: cfg.DeadLetterQueue,
+		cancelExchange:  cfg.CancelExchange,
+		retry:           retry,
+	}
+
+	if err := s.declare(cfg.QueueName); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *RabbitMQService) declare(queueName string) error {
+	q, err := s.channel.QueueDeclare(
+		queueName,
 		true,
 		false,
 		false,
@@ -37,23 +71,72 @@ func NewRabbitMQService(cfg config.RabbitMQConfig) (*RabbitMQService, error) {
 		nil,
 	)
 	if err != nil {
-		ch.Close()
-		conn.Close()
-		return nil, fmt.Errorf("declare queue failed (queue=%s): %w", cfg.QueueName, err)
+		return fmt.Errorf("declare queue failed (queue=%s): %w", queueName, err)
+	}
+	s.queue = q
+
+	err = s.channel.ExchangeDeclare(
+		s.statusExchange,
+		amqp.ExchangeTopic,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("declare exchange failed (exchange=%s): %w", s.statusExchange, err)
+	}
+
+	if _, err := s.channel.QueueDeclare(s.deadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare queue failed (queue=%s): %w", s.deadLetterQueue, err)
 	}
 
-	return &RabbitMQService{
-		conn:    conn,
-		channel: ch,
-		queue:   q,
-	}, nil
+	err = s.channel.ExchangeDeclare(
+		s.cancelExchange,
+		amqp.ExchangeFanout,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("declare exchange failed (exchange=%s): %w", s.cancelExchange, err)
+	}
+
+	// Each retry attempt waits in its own TTL queue which dead-letters back
+	// into the job queue once the delay has passed.
+	for attempt := 1; attempt <= s.retry.MaxRetries; attempt++ {
+		delay := s.retry.Delay(attempt)
+		name := retryQueueName(queueName, delay)
+
+		_, err := s.channel.QueueDeclare(name, true, false, false, false, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		})
+		if err != nil {
+			return fmt.Errorf("declare queue failed (queue=%s): %w", name, err)
+		}
+	}
+
+	return nil
 }
 
-func (s *RabbitMQService) Consume(handler func(models.JobMessage)) error {
+// Consume runs concurrency workers over the job queue until ctx is cancelled.
+// On shutdown it stops taking deliveries, waits up to drainTimeout for
+// in-flight jobs, then cancels them; interrupted jobs are requeued.
+func (s *RabbitMQService) Consume(ctx context.Context, handler JobHandler, concurrency int, drainTimeout time.Duration) error {
+	if err := s.channel.Qos(concurrency, 0, false); err != nil {
+		return fmt.Errorf("set prefetch failed: %w", err)
+	}
+
+	consumerTag := fmt.Sprintf("avcompression-%d", time.Now().UnixNano())
 	msgs, err := s.channel.Consume(
 		s.queue.Name,
-		"",
-		true,
+		consumerTag,
+		false,
 		false,
 		false,
 		false,
@@ -63,20 +146,283 @@ func (s *RabbitMQService) Consume(handler func(models.JobMessage)) error {
 		return fmt.Errorf("start consuming failed: %w", err)
 	}
 
-	forever := make(chan bool)
+	connClosed := s.conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range msgs {
+				if ctx.Err() != nil {
+					// Prefetched but not started: hand it back to another worker.
+					msg.Nack(false, true)
+					continue
+				}
+				s.handleDelivery(jobCtx, msg, handler)
+			}
+		}()
+	}
 
+	done := make(chan struct{})
 	go func() {
-		for msg := range msgs {
-			var job models.JobMessage
-			if err := json.Unmarshal(msg.Body, &job); err != nil {
-				logrus.WithError(err).Error("Failed to unmarshal job message")
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case amqpErr := <-connClosed:
+		return fmt.Errorf("rabbitmq connection closed: %v", amqpErr)
+	case <-done:
+		return fmt.Errorf("delivery channel closed")
+	}
+
+	logrus.WithField("timeout", drainTimeout).Info("Stopping consumer, draining in-flight jobs")
+	if err := s.channel.Cancel(consumerTag, false); err != nil {
+		logrus.WithError(err).Warn("Failed to cancel consumer")
+	}
+
+	select {
+	case <-done:
+		logrus.Info("All in-flight jobs finished")
+		return nil
+	case <-time.After(drainTimeout):
+	}
+
+	logrus.Warn("Drain timeout reached, interrupting remaining jobs")
+	cancelJobs()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		// Anything still unacknowledged is requeued by the broker once the
+		// connection closes.
+		logrus.Warn("Jobs did not stop in time, leaving them to the broker")
+	}
+
+	return nil
+}
+
+// ConsumeCancels hands cancel requests to the registry until ctx is
+// cancelled. Each worker binds its own exclusive queue to the cancel
+// exchange, so every worker sees every request.
+func (s *RabbitMQService) ConsumeCancels(ctx context.Context, registry *CancelRegistry) error {
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel failed: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return fmt.Errorf("declare cancel queue failed: %w", err)
+	}
+	if err := ch.QueueBind(q.Name, "", s.cancelExchange, false, nil); err != nil {
+		return fmt.Errorf("bind cancel queue failed (exchange=%s): %w", s.cancelExchange, err)
+	}
+
+	msgs, err := ch.ConsumeWithContext(ctx, q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("start consuming cancels failed: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-msgs:
+			if !ok {
+				return fmt.Errorf("cancel delivery channel closed")
+			}
+
+			var cancel models.CancelMessage
+			if err := json.Unmarshal(msg.Body, &cancel); err != nil || cancel.UUID == "" {
+				logrus.WithError(err).Warn("Dropping malformed cancel message")
 				continue
 			}
-			go handler(job)
+
+			running := registry.Cancel(cancel.UUID)
+			logrus.WithFields(logrus.Fields{
+				"job_uuid": cancel.UUID,
+				"running":  running,
+			}).Info("Job cancel received")
+		}
+	}
+}
+
+func (s *RabbitMQService) handleDelivery(ctx context.Context, msg amqp.Delivery, handler JobHandler) {
+	var job models.JobMessage
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		logrus.WithError(err).Error("Failed to unmarshal job message")
+		s.settle(msg, s.deadLetter(msg, err))
+		return
+	}
+
+	err := handler.HandleJob(ctx, job)
+	if err == nil {
+		msg.Ack(false)
+		return
+	}
+
+	if errors.Is(err, ErrJobCancelled) {
+		// Cancelled jobs are dropped rather than retried.
+		msg.Ack(false)
+		return
+	}
+
+	if ctx.Err() != nil {
+		// Interrupted by shutdown rather than a job failure; does not count as an attempt.
+		logrus.WithField("job_uuid", job.UUID).Warn("Job interrupted, requeueing")
+		msg.Nack(false, true)
+		return
+	}
+
+	attempt := retryCount(msg.Headers) + 1
+	log := logrus.WithFields(logrus.Fields{
+		"job_uuid": job.UUID,
+		"attempt":  attempt,
+	})
+
+	if errors.Is(err, ErrJobTimeout) && !s.retry.RetryTimeouts {
+		log.WithError(err).Error("Job timed out, moving to dead-letter queue")
+		handler.HandleDeadLetter(job, err)
+		s.settle(msg, s.deadLetter(msg, err))
+		return
+	}
+
+	if attempt > s.retry.MaxRetries {
+		log.WithError(err).Error("Job exhausted retries, moving to dead-letter queue")
+		handler.HandleDeadLetter(job, err)
+		s.settle(msg, s.deadLetter(msg, err))
+		return
+	}
+
+	delay := s.retry.Delay(attempt)
+	log.WithField("delay", delay).Warn("Job failed, scheduling retry")
+	s.settle(msg, s.publishRetry(msg, attempt, delay, err))
+}
+
+// settle acknowledges a delivery once it has been re-published elsewhere, or
+// returns it to the queue if re-publishing failed.
+func (s *RabbitMQService) settle(msg amqp.Delivery, publishErr error) {
+	if publishErr != nil {
+		logrus.WithError(publishErr).Error("Failed to re-route job message, requeueing")
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
+}
+
+func (s *RabbitMQService) publishRetry(msg amqp.Delivery, attempt int, delay time.Duration, jobErr error) error {
+	headers := copyHeaders(msg.Headers)
+	headers[headerRetryCount] = int32(attempt)
+	headers[headerLastError] = failureReason(jobErr)
+
+	return s.republish(context.Background(), retryQueueName(s.queue.Name, delay), msg, headers)
+}
+
+func (s *RabbitMQService) deadLetter(msg amqp.Delivery, jobErr error) error {
+	headers := copyHeaders(msg.Headers)
+	headers[headerLastError] = failureReason(jobErr)
+	headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	return s.republish(context.Background(), s.deadLetterQueue, msg, headers)
+}
+
+func (s *RabbitMQService) republish(ctx context.Context, queue string, msg amqp.Delivery, headers amqp.Table) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.channel.PublishWithContext(ctx,
+		"",
+		queue,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Headers:      headers,
+			Body:         msg.Body,
+		},
+	)
+}
+
+// ReplayDeadLetters moves dead-lettered jobs back to the job queue with their
+// retry count reset. A non-empty uuid replays only that job; limit <= 0 means all.
+func (s *RabbitMQService) ReplayDeadLetters(ctx context.Context, limit int, uuid string) (int, error) {
+	var (
+		replayed int
+		skipped  []amqp.Delivery
+	)
+
+	// Skipped deliveries stay unacked until the end so Get does not return
+	// them again, then go back to the dead-letter queue untouched.
+	defer func() {
+		for _, msg := range skipped {
+			msg.Nack(false, true)
 		}
 	}()
 
-	<-forever
+	for limit <= 0 || replayed < limit {
+		msg, ok, err := s.channel.Get(s.deadLetterQueue, false)
+		if err != nil {
+			return replayed, fmt.Errorf("get from dead-letter queue failed: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		if uuid != "" {
+			var job models.JobMessage
+			if err := json.Unmarshal(msg.Body, &job); err != nil || job.UUID != uuid {
+				skipped = append(skipped, msg)
+				continue
+			}
+		}
+
+		headers := copyHeaders(msg.Headers)
+		delete(headers, headerRetryCount)
+		delete(headers, headerLastError)
+		delete(headers, headerFailedAt)
+
+		if err := s.republish(ctx, s.queue.Name, msg, headers); err != nil {
+			msg.Nack(false, true)
+			return replayed, fmt.Errorf("republish job failed: %w", err)
+		}
+		msg.Ack(false)
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// PublishStatus sends a job status event to the status exchange, routed by status.
+func (s *RabbitMQService) PublishStatus(ctx context.Context, event models.StatusEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal status event failed: %w", err)
+	}
+
+	err = s.channel.PublishWithContext(ctx,
+		s.statusExchange,
+		string(event.Status),
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    event.Timestamp,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("publish status failed (exchange=%s): %w", s.statusExchange, err)
+	}
+
 	return nil
 }
 
@@ -88,3 +434,11 @@ func (s *RabbitMQService) Close() {
 		s.conn.Close()
 	}
 }
+
+func copyHeaders(headers amqp.Table) amqp.Table {
+	copied := make(amqp.Table, len(headers)+3)
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return copied
+}
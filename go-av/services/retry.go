@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	headerRetryCount = "x-retry-count"
+	headerLastError  = "x-last-error"
+	headerFailedAt   = "x-failed-at"
+
+	maxRetryDelay = time.Hour
+)
+
+// RetryPolicy controls how failed jobs are re-delivered before being dead-lettered.
+type RetryPolicy struct {
+	MaxRetries    int
+	Backoff       time.Duration
+	RetryTimeouts bool
+}
+
+// Delay returns the exponential backoff before the given retry attempt (1-based).
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// retryQueueName names the delay queue for an attempt after its TTL, so a
+// changed backoff declares new queues instead of conflicting with old ones.
+func retryQueueName(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queue, delay)
+}
+
+func retryCount(headers amqp.Table) int {
+	switch v := headers[headerRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/resoul/avcompression/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SubtitlesBurn = "burn"
+	SubtitlesSoft = "soft"
+
+	// subtitleInput is the ffmpeg input index of a soft subtitle track; it
+	// always follows the media and audio inputs.
+	subtitleInput = 2
+)
+
+// subtitleTrack is a downloaded subtitle file and how to render it.
+type subtitleTrack struct {
+	path  string
+	burn  bool
+	style string
+	codec string
+}
+
+// prepareSubtitles downloads the job's subtitle file, if any, and checks it
+// can be rendered with the preset.
+func (p *Processor) prepareSubtitles(ctx context.Context, job models.JobMessage, tmpDir string, preset Preset) (*subtitleTrack, error) {
+	subs := job.Subtitles
+	if subs == nil || subs.Path == "" {
+		return nil, nil
+	}
+
+	if preset.AudioOnly() {
+		return nil, fmt.Errorf("subtitles need a video preset, got %s", preset.Name)
+	}
+
+	ext := strings.ToLower(filepath.Ext(subs.Path))
+	switch ext {
+	case ".srt", ".vtt", ".ass":
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %s", ext)
+	}
+
+	track := &subtitleTrack{codec: preset.SubtitleCodec}
+
+	switch subs.Mode {
+	case "", SubtitlesBurn:
+		track.burn = true
+		style, err := subtitleStyle(subs)
+		if err != nil {
+			return nil, err
+		}
+		track.style = style
+	case SubtitlesSoft:
+		if track.codec == "" {
+			return nil, fmt.Errorf("preset %s cannot carry soft subtitles", preset.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown subtitles mode: %s", subs.Mode)
+	}
+
+	// A fixed name keeps the path free of characters the filter parser would
+	// need escaped.
+	track.path = filepath.Join(tmpDir, "subtitles"+ext)
+	if err := p.minio.DownloadFile(ctx, job.Bucket, subs.Path, track.path); err != nil {
+		return nil, fmt.Errorf("download subtitles: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"file": filepath.Base(subs.Path),
+		"mode": subs.Mode,
+	}).Debug("Downloaded subtitles")
+
+	return track, nil
+}
+
+// subtitleStyle converts the job's styling options into an ASS force_style.
+func subtitleStyle(subs *models.Subtitles) (string, error) {
+	var fields []string
+
+	if subs.FontSize < 0 {
+		return "", fmt.Errorf("invalid subtitles font size: %d", subs.FontSize)
+	}
+	if subs.FontSize > 0 {
+		fields = append(fields, "FontSize="+strconv.Itoa(subs.FontSize))
+	}
+
+	if subs.Color != "" {
+		hex := strings.TrimPrefix(subs.Color, "#")
+		if _, err := strconv.ParseUint(hex, 16, 32); err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("invalid subtitles color: %s (expected #RRGGBB)", subs.Color)
+		}
+		// ASS colours are written as &HAABBGGRR.
+		hex = strings.ToUpper(hex)
+		fields = append(fields, fmt.Sprintf("PrimaryColour=&H00%s%s%s", hex[4:6], hex[2:4], hex[0:2]))
+	}
+
+	return strings.Join(fields, ","), nil
+}
+
+// burnsIn reports whether the subtitles are drawn into the picture.
+func (s *subtitleTrack) burnsIn() bool {
+	return s != nil && s.burn
+}
+
+// filter returns the suffix appended to a video filter chain to burn the
+// subtitles in, or "" when they are not burned in.
+func (s *subtitleTrack) filter() string {
+	if !s.burnsIn() {
+		return ""
+	}
+	filter := ",subtitles=f=" + s.path
+	if s.style != "" {
+		filter += ":force_style='" + s.style + "'"
+	}
+	return filter
+}
+
+// inputArgs adds a soft subtitle file as input subtitleInput.
+func (s *subtitleTrack) inputArgs() []string {
+	if s == nil || s.burn {
+		return nil
+	}
+	return []string{"-i", s.path}
+}
+
+// outputArgs maps a soft subtitle track into the output.
+func (s *subtitleTrack) outputArgs() []string {
+	if s == nil || s.burn {
+		return nil
+	}
+	return []string{
+		"-map", fmt.Sprintf("%d:s", subtitleInput),
+		"-c:s", s.codec,
+	}
+}
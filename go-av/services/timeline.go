@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/resoul/avcompression/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TimelineAssetDir is the folder under the job prefix holding timeline assets.
+	TimelineAssetDir = "assets"
+
+	timelineFrameRate         = 30
+	defaultTransitionDuration = 1.0
+	duckingFilter             = "sidechaincompress=threshold=0.03:ratio=8:attack=50:release=500"
+)
+
+// timelineClip is a timeline item resolved against its downloaded asset.
+type timelineClip struct {
+	path               string
+	info               *MediaInfo
+	start              float64
+	duration           float64
+	crossfade          bool
+	transitionDuration float64
+}
+
+// timelineTrack is a music or voiceover track resolved against its asset.
+type timelineTrack struct {
+	path   string
+	volume float64
+	start  float64
+}
+
+// composeTimeline downloads the job's manifest and assets and renders the
+// timeline into outputPath. It returns the output size.
+func (p *Processor) composeTimeline(ctx context.Context, job models.JobMessage, tmpDir, outputPath string, preset Preset, result *models.JobResult, onProgress func(percent float64)) (int, int, error) {
+	if preset.AudioOnly() {
+		return 0, 0, fmt.Errorf("timeline jobs need a video preset, got %s", preset.Name)
+	}
+
+	timeline, err := p.loadTimeline(ctx, job, tmpDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	assetsLocal := filepath.Join(tmpDir, TimelineAssetDir)
+	if err := os.MkdirAll(assetsLocal, os.ModePerm); err != nil {
+		return 0, 0, fmt.Errorf("create assets dir: %w", err)
+	}
+
+	downloaded := make(map[string]string)
+	fetch := func(asset string) (string, error) {
+		if local, ok := downloaded[asset]; ok {
+			return local, nil
+		}
+		if asset == "" || asset != path.Base(asset) {
+			return "", fmt.Errorf("invalid asset name: %q", asset)
+		}
+		local := filepath.Join(assetsLocal, asset)
+		object := path.Join(job.UUID, TimelineAssetDir, asset)
+		if err := p.minio.DownloadFile(ctx, job.Bucket, object, local); err != nil {
+			return "", fmt.Errorf("download asset %s: %w", asset, err)
+		}
+		downloaded[asset] = local
+		return local, nil
+	}
+
+	clips, err := p.resolveTimelineItems(ctx, timeline.Items, job.AssetInfo, fetch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	music, err := resolveTimelineTrack(timeline.Music, fetch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("music: %w", err)
+	}
+	voiceover, err := resolveTimelineTrack(timeline.Voiceover, fetch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("voiceover: %w", err)
+	}
+	p.reportStatus(job, models.JobStatusProcessing, progressDownloaded, nil)
+
+	width, height := timeline.Width, timeline.Height
+	if width <= 0 || height <= 0 {
+		width, height = p.calculateTargetResolution(clips[0].info.Width, clips[0].info.Height)
+	}
+	width, height = evenDimension(float64(width)), evenDimension(float64(height))
+
+	// The mix only exists once rendered, so loudness is normalized afterwards.
+	renderPath := outputPath
+	if job.Loudnorm != nil {
+		renderPath = filepath.Join(tmpDir, "mixed"+filepath.Ext(outputPath))
+	}
+
+	cmd, duration := p.buildTimelineCommand(ctx, clips, music, voiceover, timeline.Ducking, renderPath, width, height, preset)
+
+	logrus.WithFields(logrus.Fields{
+		"items":    len(clips),
+		"assets":   len(downloaded),
+		"width":    width,
+		"height":   height,
+		"duration": duration,
+	}).Debug("Rendering timeline")
+
+	if err := runFFmpeg(cmd, duration, onProgress); err != nil {
+		return 0, 0, err
+	}
+
+	if job.Loudnorm != nil {
+		if err := p.normalizeTimelineAudio(ctx, renderPath, outputPath, tmpDir, preset, job.Loudnorm, result); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return width, height, nil
+}
+
+// normalizeTimelineAudio normalizes the loudness of a rendered timeline's
+// audio and muxes it back in, copying the video stream.
+func (p *Processor) normalizeTimelineAudio(ctx context.Context, renderPath, outputPath, tmpDir string, preset Preset, opts *models.Loudnorm, result *models.JobResult) error {
+	normalizedLocal := filepath.Join(tmpDir, "normalized.flac")
+	stats, err := p.normalizeLoudness(ctx, renderPath, normalizedLocal, opts)
+	switch {
+	case errors.Is(err, errSilentAudio):
+		logrus.Warn("Timeline audio is silent, skipping loudness normalization")
+		return os.Rename(renderPath, outputPath)
+	case err != nil:
+		return err
+	}
+
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-i", renderPath,
+		"-i", normalizedLocal,
+		"-map", "0:v",
+		"-map", "1:a",
+		"-c:v", "copy",
+	}
+	args = append(args, preset.AudioArgs...)
+	args = append(args, preset.ContainerArgs...)
+	args = append(args, "-y", outputPath)
+
+	if output, err := newCommand(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("mux normalized audio: %w\nOutput: %s", err, output)
+	}
+
+	result.Loudness = stats
+	return nil
+}
+
+func (p *Processor) loadTimeline(ctx context.Context, job models.JobMessage, tmpDir string) (*models.Timeline, error) {
+	if job.TimelinePath == "" {
+		return nil, fmt.Errorf("timeline job without a manifest")
+	}
+
+	local := filepath.Join(tmpDir, path.Base(job.TimelinePath))
+	if err := p.minio.DownloadFile(ctx, job.Bucket, job.TimelinePath, local); err != nil {
+		return nil, fmt.Errorf("download timeline: %w", err)
+	}
+
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return nil, fmt.Errorf("read timeline: %w", err)
+	}
+
+	var timeline models.Timeline
+	if err := json.Unmarshal(data, &timeline); err != nil {
+		return nil, fmt.Errorf("parse timeline: %w", err)
+	}
+	if len(timeline.Items) == 0 {
+		return nil, fmt.Errorf("timeline has no items")
+	}
+
+	return &timeline, nil
+}
+
+// resolveTimelineItems downloads every item, probes those the API did not
+// and settles its duration and transition.
+func (p *Processor) resolveTimelineItems(ctx context.Context, items []models.TimelineItem, probes map[string]*models.MediaInfo, fetch func(string) (string, error)) ([]timelineClip, error) {
+	clips := make([]timelineClip, 0, len(items))
+
+	for i, item := range items {
+		local, err := fetch(item.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		info, probed := probedMedia(probes[item.Asset])
+		if !probed {
+			if info, err = p.analyzeMedia(ctx, local); err != nil {
+				return nil, fmt.Errorf("item %d: analyze media: %w", i, err)
+			}
+		}
+
+		clip := timelineClip{path: local, info: info, duration: item.Duration}
+
+		if info.Type == MediaTypeImage {
+			if clip.duration <= 0 {
+				return nil, fmt.Errorf("item %d: image %s needs a duration", i, item.Asset)
+			}
+		} else {
+			clip.start = max(item.Start, 0)
+			remaining := info.Duration - clip.start
+			if remaining <= 0 {
+				return nil, fmt.Errorf("item %d: start %.2fs is past the end of %s", i, clip.start, item.Asset)
+			}
+			if clip.duration <= 0 || clip.duration > remaining {
+				clip.duration = remaining
+			}
+		}
+
+		switch item.Transition {
+		case "", models.TransitionCut:
+		case models.TransitionCrossfade:
+			if i == 0 {
+				break
+			}
+			clip.crossfade = true
+			clip.transitionDuration = item.TransitionDuration
+			if clip.transitionDuration <= 0 {
+				clip.transitionDuration = defaultTransitionDuration
+			}
+			if prev := clips[i-1]; clip.transitionDuration >= min(prev.duration, clip.duration) {
+				return nil, fmt.Errorf("item %d: crossfade of %.2fs is longer than the items it joins", i, clip.transitionDuration)
+			}
+		default:
+			return nil, fmt.Errorf("item %d: unknown transition: %s", i, item.Transition)
+		}
+
+		clips = append(clips, clip)
+	}
+
+	return clips, nil
+}
+
+func resolveTimelineTrack(track *models.TimelineAudio, fetch func(string) (string, error)) (*timelineTrack, error) {
+	if track == nil {
+		return nil, nil
+	}
+
+	local, err := fetch(track.Asset)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := track.Volume
+	if volume <= 0 {
+		volume = 1
+	}
+
+	return &timelineTrack{path: local, volume: volume, start: max(track.Start, 0)}, nil
+}
+
+// buildTimelineCommand renders the clips in sequence, joined by cuts or
+// crossfades, over the music and voiceover mix. It returns the command and
+// the length of the timeline in seconds.
+func (p *Processor) buildTimelineCommand(ctx context.Context, clips []timelineClip, music, voiceover *timelineTrack, ducking bool, outputPath string, width, height int, preset Preset) (*exec.Cmd, float64) {
+	var (
+		args    []string
+		filters []string
+	)
+
+	for i, clip := range clips {
+		if clip.info.Type == MediaTypeImage {
+			args = append(args, "-loop", "1", "-framerate", strconv.Itoa(timelineFrameRate))
+		} else {
+			args = append(args, "-ss", formatSeconds(clip.start))
+		}
+		args = append(args, "-t", formatSeconds(clip.duration), "-i", clip.path)
+
+		filters = append(filters, fmt.Sprintf(
+			"[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d,format=yuv420p,settb=AVTB,setpts=PTS-STARTPTS[v%d]",
+			i, width, height, width, height, timelineFrameRate, i,
+		))
+	}
+
+	video := "v0"
+	duration := clips[0].duration
+	for i := 1; i < len(clips); i++ {
+		clip := clips[i]
+		joined := fmt.Sprintf("x%d", i)
+
+		if clip.crossfade {
+			offset := duration - clip.transitionDuration
+			filters = append(filters, fmt.Sprintf("[%s][v%d]xfade=transition=fade:duration=%s:offset=%s[%s]",
+				video, i, formatSeconds(clip.transitionDuration), formatSeconds(offset), joined))
+			duration = offset + clip.duration
+		} else {
+			filters = append(filters, fmt.Sprintf("[%s][v%d]concat=n=2:v=1:a=0[%s]", video, i, joined))
+			duration += clip.duration
+		}
+		video = joined
+	}
+
+	input := len(clips)
+	audioTrack := func(track *timelineTrack, label string, loop bool) {
+		if loop {
+			args = append(args, "-stream_loop", "-1")
+		}
+		args = append(args, "-i", track.path)
+
+		filter := fmt.Sprintf("[%d:a]aresample=48000,aformat=channel_layouts=stereo,volume=%g", input, track.volume)
+		if track.start > 0 {
+			filter += fmt.Sprintf(",adelay=%d:all=1", int(track.start*1000))
+		}
+		filters = append(filters, fmt.Sprintf("%s,apad,atrim=duration=%s[%s]", filter, formatSeconds(duration), label))
+		input++
+	}
+
+	switch {
+	case music != nil && voiceover != nil:
+		audioTrack(music, "music", true)
+		audioTrack(voiceover, "voice", false)
+		if ducking {
+			filters = append(filters,
+				"[voice]asplit=2[voicemix][voicekey]",
+				"[music][voicekey]"+duckingFilter+"[bed]",
+				"[bed][voicemix]amix=inputs=2:duration=first:normalize=0[a]",
+			)
+		} else {
+			filters = append(filters, "[music][voice]amix=inputs=2:duration=first:normalize=0[a]")
+		}
+	case music != nil:
+		audioTrack(music, "a", true)
+	case voiceover != nil:
+		audioTrack(voiceover, "a", false)
+	default:
+		args = append(args, "-f", "lavfi", "-t", formatSeconds(duration), "-i", "anullsrc=r=48000:cl=stereo")
+		filters = append(filters, fmt.Sprintf("[%d:a]anull[a]", input))
+	}
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "["+video+"]",
+		"-map", "[a]",
+	)
+	args = append(args, preset.videoArgs(width, height, false)...)
+	args = append(args, preset.AudioArgs...)
+	args = append(args,
+		"-pix_fmt", "yuv420p",
+		"-color_range", "tv",
+		"-colorspace", "bt709",
+		"-t", formatSeconds(duration),
+	)
+	args = append(args, preset.ContainerArgs...)
+	args = append(args, ffmpegProgressArgs...)
+	args = append(args, "-y", outputPath)
+
+	return newCommand(ctx, "ffmpeg", args...), duration
+}
+
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
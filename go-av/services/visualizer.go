@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/resoul/avcompression/models"
+)
+
+const (
+	VisualizerWaveform = "waveform"
+	VisualizerSpectrum = "spectrum"
+	VisualizerCircle   = "circle"
+
+	PositionTop    = "top"
+	PositionCenter = "center"
+	PositionBottom = "bottom"
+
+	defaultVisualizerColor = "#FFFFFF"
+	visualizerFrameRate    = 30
+)
+
+// visualizer is a validated audio visualizer drawn over a still image.
+type visualizer struct {
+	style    string
+	color    string // RRGGBB
+	position string
+}
+
+// resolveVisualizer validates the job's visualizer options and fills in
+// defaults. It returns nil when the job has no visualizer.
+func resolveVisualizer(opts *models.Visualizer) (*visualizer, error) {
+	if opts == nil || opts.Style == "" || opts.Style == "none" {
+		return nil, nil
+	}
+
+	v := &visualizer{style: opts.Style, position: opts.Position}
+
+	switch v.style {
+	case VisualizerWaveform, VisualizerSpectrum:
+		if v.position == "" {
+			v.position = PositionBottom
+		}
+	case VisualizerCircle:
+		if v.position == "" {
+			v.position = PositionCenter
+		}
+	default:
+		return nil, fmt.Errorf("unknown visualizer style: %s", opts.Style)
+	}
+
+	switch v.position {
+	case PositionTop, PositionCenter, PositionBottom:
+	default:
+		return nil, fmt.Errorf("unknown visualizer position: %s", opts.Position)
+	}
+
+	color := opts.Color
+	if color == "" {
+		color = defaultVisualizerColor
+	}
+	hex := strings.TrimPrefix(color, "#")
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("invalid visualizer color: %s (expected #RRGGBB)", opts.Color)
+	}
+	v.color = strings.ToUpper(hex)
+
+	return v, nil
+}
+
+// filter renders audioInput as the visualizer into label, sized for a
+// width x height frame. It returns the overlay position for that frame.
+func (v *visualizer) filter(audioInput, label string, width, height int) (string, string) {
+	var graph string
+
+	switch v.style {
+	case VisualizerWaveform:
+		graph = fmt.Sprintf("%sshowwaves=s=%dx%d:mode=cline:rate=%d:colors=0x%s",
+			audioInput, width, evenDimension(float64(height)/4), visualizerFrameRate, v.color)
+	case VisualizerSpectrum:
+		graph = fmt.Sprintf("%sshowfreqs=s=%dx%d:mode=bar:fscale=log:ascale=sqrt:cmode=combined:colors=0x%s,fps=%d",
+			audioInput, width, evenDimension(float64(height)/4), v.color, visualizerFrameRate)
+	case VisualizerCircle:
+		r, _ := strconv.ParseUint(v.color[0:2], 16, 8)
+		g, _ := strconv.ParseUint(v.color[2:4], 16, 8)
+		b, _ := strconv.ParseUint(v.color[4:6], 16, 8)
+		size := evenDimension(float64(min(width, height)) / 2)
+		graph = fmt.Sprintf("%savectorscope=s=%dx%d:mode=lissajous:draw=line:scale=sqrt:zoom=1.5:rate=%d:rc=%d:gc=%d:bc=%d:rf=40:gf=40:bf=40",
+			audioInput, size, size, visualizerFrameRate, r, g, b)
+	}
+	graph += ",format=rgba" + label
+
+	margin := height / 20
+	var overlay string
+	switch v.position {
+	case PositionTop:
+		overlay = fmt.Sprintf("x=(W-w)/2:y=%d", margin)
+	case PositionCenter:
+		overlay = "x=(W-w)/2:y=(H-h)/2"
+	default:
+		overlay = fmt.Sprintf("x=(W-w)/2:y=H-h-%d", margin)
+	}
+
+	return graph, overlay
+}